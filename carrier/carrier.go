@@ -0,0 +1,109 @@
+// Package carrier contains functions to propagate the values of a request context into asynchronous work.
+//
+// If a request enqueues work, e.g. as message into a queue or as job into a database, the values on the context
+// like tenant, trace-id and request id are lost. Inject serializes these values into a map[string]string,
+// which can be transported as message attributes or as part of the job payload, and Extract restores them
+// into a context on the consumer side.
+//
+// Example:
+//	func enqueue(ctx context.Context, job Job) error {
+//		attributes := map[string]string{}
+//		carrier.Inject(ctx, attributes)
+//		return queue.Send(job, attributes)
+//	}
+//
+//	func consume(msg Message) {
+//		ctx := carrier.Extract(context.Background(), msg.Attributes)
+//		tenantId, _ := tenant.IdFromCtx(ctx)
+//		// ...
+//	}
+//
+// The values are restored without verification. So the carrier MUST only be read from a trusted source like
+// a queue which can't be written by others.
+package carrier
+
+import (
+	"context"
+
+	"github.com/d-velop/dvelop-sdk-go/environment"
+	"github.com/d-velop/dvelop-sdk-go/requestid"
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+	"github.com/d-velop/dvelop-sdk-go/tracecontext"
+)
+
+// Keys of the values in the carrier
+const (
+	TenantIdKey               = "x-dv-tenant-id"
+	SystemBaseUriKey          = "x-dv-baseuri"
+	InitiatorSystemBaseUriKey = "x-dv-initiator-baseuri"
+	TraceparentKey            = "traceparent"
+	TracestateKey             = "tracestate"
+	RequestIdKey              = "x-dv-request-id"
+	EnvironmentKey            = "x-dv-environment"
+)
+
+// Inject writes the values of the packages tenant, tracecontext, requestid and environment from the context
+// into the carrier. Values which aren't present on the context are omitted.
+func Inject(ctx context.Context, carrier map[string]string) {
+	setIfPresent(ctx, carrier, TenantIdKey, tenant.IdFromCtx)
+	setIfPresent(ctx, carrier, SystemBaseUriKey, tenant.SystemBaseUriFromCtx)
+	setIfPresent(ctx, carrier, InitiatorSystemBaseUriKey, tenant.InitiatorSystemBaseUriFromCtx)
+	setIfPresent(ctx, carrier, TraceparentKey, tracecontext.TraceparentFromCtx)
+	if ts, err := tracecontext.TracestateFromCtx(ctx); err == nil && ts.Len() > 0 {
+		carrier[TracestateKey] = ts.String()
+	}
+	setIfPresent(ctx, carrier, RequestIdKey, requestid.FromCtx)
+	if e := environment.Get(ctx); e != "" {
+		carrier[EnvironmentKey] = e
+	}
+}
+
+// ToMap returns a new carrier with the values of the context. Cf. Inject.
+func ToMap(ctx context.Context) map[string]string {
+	carrier := map[string]string{}
+	Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract returns a new context.Context derived from ctx which contains the values of the carrier.
+//
+// The trace-id and the trace-flags are restored from the traceparent together with the tracestate and a new span-id is generated, because the asynchronous work
+// is a new unit of work within the same trace. The span-id of the injecting context becomes the parent span-id.
+// Missing or invalid values are skipped.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if v, ok := carrier[TenantIdKey]; ok {
+		ctx = tenant.SetId(ctx, v)
+	}
+	if v, ok := carrier[SystemBaseUriKey]; ok {
+		ctx = tenant.SetSystemBaseUri(ctx, v)
+	}
+	if v, ok := carrier[InitiatorSystemBaseUriKey]; ok {
+		ctx = tenant.SetInitiatorSystemBaseUri(ctx, v)
+	}
+	if v, ok := carrier[TraceparentKey]; ok {
+		if tp, err := tracecontext.ParseTraceparent(v); err == nil {
+			ctx = tracecontext.WithTraceIdCtx(ctx, tp.TraceId())
+			ctx = tracecontext.WithTraceFlagsCtx(ctx, tp.TraceFlags())
+			ctx = tracecontext.WithParentSpanIdCtx(ctx, tp.ParentId())
+			if spanId, err := tracecontext.NewSpanId(); err == nil {
+				ctx = tracecontext.WithSpanIdCtx(ctx, spanId)
+			}
+			if ts, err := tracecontext.ParseTracestate(carrier[TracestateKey]); err == nil && ts.Len() > 0 {
+				ctx = tracecontext.WithTracestateCtx(ctx, ts)
+			}
+		}
+	}
+	if v, ok := carrier[RequestIdKey]; ok {
+		ctx = requestid.Set(ctx, v)
+	}
+	if v, ok := carrier[EnvironmentKey]; ok {
+		ctx = environment.Set(ctx, v)
+	}
+	return ctx
+}
+
+func setIfPresent(ctx context.Context, carrier map[string]string, key string, fromCtx func(ctx context.Context) (string, error)) {
+	if value, err := fromCtx(ctx); err == nil && value != "" {
+		carrier[key] = value
+	}
+}
@@ -0,0 +1,117 @@
+package carrier_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/carrier"
+	"github.com/d-velop/dvelop-sdk-go/environment"
+	"github.com/d-velop/dvelop-sdk-go/requestid"
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+	"github.com/d-velop/dvelop-sdk-go/tracecontext"
+)
+
+const (
+	traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	spanId  = "00f067aa0ba902b7"
+)
+
+func TestContextWithValues_Inject_WritesValuesIntoCarrier(t *testing.T) {
+	ctx := contextWithAllValues()
+	c := map[string]string{"other": "value"}
+
+	carrier.Inject(ctx, c)
+
+	want := map[string]string{
+		"other":                  "value",
+		"x-dv-tenant-id":         "a12be5",
+		"x-dv-baseuri":           "https://sample.example.com",
+		"x-dv-initiator-baseuri": "https://initiator.example.com",
+		"traceparent":            "00-" + traceId + "-" + spanId + "-01",
+		"tracestate":             "congo=t61rcWkgMzE",
+		"x-dv-request-id":        "1234",
+		"x-dv-environment":       "dev",
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("wrong carrier: got %v want %v", c, want)
+	}
+}
+
+func TestEmptyContext_ToMap_ReturnsEmptyCarrier(t *testing.T) {
+	if c := carrier.ToMap(context.Background()); len(c) != 0 {
+		t.Errorf("carrier should be empty: got %v", c)
+	}
+}
+
+func TestCarrier_Extract_RestoresValuesIntoContext(t *testing.T) {
+	ctx := carrier.Extract(context.Background(), carrier.ToMap(contextWithAllValues()))
+
+	assertValue(t, "tenant id", "a12be5", tenant.IdFromCtx, ctx)
+	assertValue(t, "systemBaseUri", "https://sample.example.com", tenant.SystemBaseUriFromCtx, ctx)
+	assertValue(t, "initiatorSystemBaseUri", "https://initiator.example.com", tenant.InitiatorSystemBaseUriFromCtx, ctx)
+	assertValue(t, "trace id", traceId, tracecontext.TraceIdFromCtx, ctx)
+	assertValue(t, "parent span id", spanId, tracecontext.ParentSpanIdFromCtx, ctx)
+	assertValue(t, "request id", "1234", requestid.FromCtx, ctx)
+	if e := environment.Get(ctx); e != "dev" {
+		t.Errorf("wrong environment: got %v want %v", e, "dev")
+	}
+	if ts, err := tracecontext.TracestateFromCtx(ctx); err != nil || ts.String() != "congo=t61rcWkgMzE" {
+		t.Errorf("wrong tracestate: got %v, %v want %v", ts, err, "congo=t61rcWkgMzE")
+	}
+	if s, err := tracecontext.SpanIdFromCtx(ctx); err != nil || s == spanId {
+		t.Errorf("expected new span id: got %v, %v", s, err)
+	}
+}
+
+func TestEmptyCarrier_Extract_ReturnsContextWithoutValues(t *testing.T) {
+	ctx := carrier.Extract(context.Background(), map[string]string{})
+
+	if _, err := tenant.IdFromCtx(ctx); err == nil {
+		t.Error("expected no tenant id on context")
+	}
+	if _, err := tracecontext.TraceIdFromCtx(ctx); err == nil {
+		t.Error("expected no trace id on context")
+	}
+	if _, err := requestid.FromCtx(ctx); err == nil {
+		t.Error("expected no request id on context")
+	}
+}
+
+func TestInvalidTraceparent_Extract_SkipsTraceparent(t *testing.T) {
+	ctx := carrier.Extract(context.Background(), map[string]string{"traceparent": "invalid", "x-dv-tenant-id": "a12be5"})
+
+	if _, err := tracecontext.TraceIdFromCtx(ctx); err == nil {
+		t.Error("expected no trace id on context")
+	}
+	assertValue(t, "tenant id", "a12be5", tenant.IdFromCtx, ctx)
+}
+
+func TestNotSampledTraceparent_Extract_RestoresTraceFlags(t *testing.T) {
+	ctx := carrier.Extract(context.Background(), map[string]string{"traceparent": "00-" + traceId + "-" + spanId + "-00"})
+
+	if tracecontext.IsSampled(ctx) {
+		t.Error("expected trace not to be sampled")
+	}
+}
+
+func contextWithAllValues() context.Context {
+	ctx := context.Background()
+	ctx = tenant.SetId(ctx, "a12be5")
+	ctx = tenant.SetSystemBaseUri(ctx, "https://sample.example.com")
+	ctx = tenant.SetInitiatorSystemBaseUri(ctx, "https://initiator.example.com")
+	ctx = tracecontext.WithTraceIdCtx(ctx, traceId)
+	ctx = tracecontext.WithSpanIdCtx(ctx, spanId)
+	tracestate, _ := tracecontext.ParseTracestate("congo=t61rcWkgMzE")
+	ctx = tracecontext.WithTracestateCtx(ctx, tracestate)
+	ctx = requestid.Set(ctx, "1234")
+	ctx = environment.Set(ctx, "dev")
+	return ctx
+}
+
+func assertValue(t *testing.T, name string, want string, fromCtx func(context.Context) (string, error), ctx context.Context) {
+	t.Helper()
+	if got, err := fromCtx(ctx); err != nil || got != want {
+		t.Errorf("wrong %v: got %v, %v want %v", name, got, err, want)
+	}
+}
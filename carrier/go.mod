@@ -0,0 +1,25 @@
+module github.com/d-velop/dvelop-sdk-go/carrier
+
+require (
+	github.com/d-velop/dvelop-sdk-go/environment v0.0.0-20261018212530-0c0d195df3de
+	github.com/d-velop/dvelop-sdk-go/requestid v0.0.0-20261018210541-45fb7b9eabfe
+	github.com/d-velop/dvelop-sdk-go/tenant v0.0.0-20261018214011-2b81a3e79421
+	github.com/d-velop/dvelop-sdk-go/tracecontext v0.0.0-20261018214006-0933dda16218
+)
+
+require (
+	github.com/d-velop/dvelop-sdk-go/otellog v0.0.0-20261018211045-1ec5ae314232 // indirect
+	github.com/satori/go.uuid v1.2.1-0.20181028125025-b2ce2384e17b // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+)
+
+// local development only, replace directives are ignored when this module is required by other modules
+replace (
+	github.com/d-velop/dvelop-sdk-go/environment => ../environment
+	github.com/d-velop/dvelop-sdk-go/otellog => ../otellog
+	github.com/d-velop/dvelop-sdk-go/requestid => ../requestid
+	github.com/d-velop/dvelop-sdk-go/tenant => ../tenant
+	github.com/d-velop/dvelop-sdk-go/tracecontext => ../tracecontext
+)
+
+go 1.17
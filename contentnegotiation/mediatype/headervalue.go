@@ -4,12 +4,13 @@ import (
 	"errors"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 var (
 	ErrSyntax = errors.New("headervalue: has wrong syntax")
 
-	withQualityRegexp, _ = regexp.Compile("^\\s*([^\\s;]+)(\\s*;\\s*q=(\\d?[.]\\d+))*")
+	qualityRegexp, _ = regexp.Compile("^\\d*[.]?\\d*$")
 )
 
 type headervalue struct {
@@ -17,22 +18,31 @@ type headervalue struct {
 	Quality float64
 }
 
+// parseHeaderValue parses a single element of a header value like "text/html;level=1;q=0.8".
+// Parameters apart from the quality are ignored.
 func parseHeaderValue(s string) (*headervalue, error) {
-	h := withQualityRegexp.FindStringSubmatch(s)
-
-	if h == nil {
+	parts := strings.Split(s, ";")
+	value := strings.TrimSpace(parts[0])
+	if value == "" || strings.ContainsAny(value, " \t") {
 		return nil, ErrSyntax
 	}
 
-	if h[2] != "" {
-		q, err := strconv.ParseFloat(h[3], 64)
-
-		if err != nil {
+	for _, p := range parts[1:] {
+		param := strings.SplitN(p, "=", 2)
+		if len(param) != 2 || !strings.EqualFold(strings.TrimSpace(param[0]), "q") {
+			continue
+		}
+		qValue := strings.TrimSpace(param[1])
+		if qValue == "" || qValue == "." || !qualityRegexp.MatchString(qValue) {
+			return nil, ErrSyntax
+		}
+		q, err := strconv.ParseFloat(qValue, 64)
+		if err != nil || q > 1 {
 			return nil, ErrSyntax
 		}
-		return &headervalue{Value: h[1], Quality: q}, nil
+		return &headervalue{Value: value, Quality: q}, nil
 	}
-	return &headervalue{Value: h[1], Quality: 1.0}, nil
+	return &headervalue{Value: value, Quality: 1.0}, nil
 }
 
 type headervalues []*headervalue
@@ -53,12 +53,17 @@ func Negotiate(acceptHeader string, supportedTypes []string) (*Mediatype, error)
 	}
 
 	tokens := strings.Split(acceptHeader, ",")
-	mediaranges := make([]*headervalue, len(tokens))
-	for k, r := range tokens {
-		x, _ := parseHeaderValue(r)
-		mediaranges[k] = x
+	mediaranges := make([]*headervalue, 0, len(tokens))
+	for _, r := range tokens {
+		x, err := parseHeaderValue(r)
+		if err != nil || x.Quality == 0 {
+			// skip invalid mediaranges and those which are explicitly marked as not acceptable
+			continue
+		}
+		mediaranges = append(mediaranges, x)
 	}
-	sort.Sort(sort.Reverse(headervalues(mediaranges)))
+	// stable sort preserves the order of mediaranges with equal quality
+	sort.Stable(sort.Reverse(headervalues(mediaranges)))
 
 	for _, mr := range mediaranges {
 		for _, t := range supportedTypes {
@@ -86,3 +86,31 @@ func (input *negotiateWith) shouldReturnMediatype(t *testing.T, expectedMediatyp
 		t.Fatalf("Negotiate(%v) with 'Accept:%v': expected %v but got %v", input.supportedTypes, input.acceptHeader, expectedMediatype, m)
 	}
 }
+
+func TestRequestedTypeHasQualityZero_ReturnsErrorNotSupported(t *testing.T) {
+	(&negotiateWith{acceptHeader: "text/html; q=0", supportedTypes: []string{"text/html"}}).shouldReturnErrorNotSupported(t)
+	(&negotiateWith{acceptHeader: "text/html; q=0.0", supportedTypes: []string{"text/html"}}).shouldReturnErrorNotSupported(t)
+	(&negotiateWith{acceptHeader: "text/html; q=0.", supportedTypes: []string{"text/html"}}).shouldReturnErrorNotSupported(t)
+	(&negotiateWith{acceptHeader: "application/json, */*; q=0", supportedTypes: []string{"text/html"}}).shouldReturnErrorNotSupported(t)
+}
+
+func TestQualityWithoutFraction_ReturnsType(t *testing.T) {
+	(&negotiateWith{acceptHeader: "text/html; q=1", supportedTypes: []string{"text/html"}}).shouldReturnMediatype(t, "text/html")
+	(&negotiateWith{acceptHeader: "text/html;Q=1", supportedTypes: []string{"text/html"}}).shouldReturnMediatype(t, "text/html")
+}
+
+func TestInvalidQuality_IgnoresMediarange(t *testing.T) {
+	(&negotiateWith{acceptHeader: "text/html; q=2", supportedTypes: []string{"text/html"}}).shouldReturnErrorNotSupported(t)
+	(&negotiateWith{acceptHeader: "text/html; q=NaN", supportedTypes: []string{"text/html"}}).shouldReturnErrorNotSupported(t)
+	(&negotiateWith{acceptHeader: "text/html; q=", supportedTypes: []string{"text/html"}}).shouldReturnErrorNotSupported(t)
+}
+
+func TestEmptyMediaranges_AreIgnored(t *testing.T) {
+	(&negotiateWith{acceptHeader: "text/html, ,", supportedTypes: []string{"text/html"}}).shouldReturnMediatype(t, "text/html")
+	(&negotiateWith{acceptHeader: " ", supportedTypes: []string{"text/html"}}).shouldReturnErrorNotSupported(t)
+}
+
+func TestRequestedTypesWithEqualQuality_ReturnsFirstRequestedType(t *testing.T) {
+	(&negotiateWith{acceptHeader: "application/json, text/html", supportedTypes: []string{"text/html", "application/json"}}).shouldReturnMediatype(t, "application/json")
+	(&negotiateWith{acceptHeader: "text/html, application/json", supportedTypes: []string{"text/html", "application/json"}}).shouldReturnMediatype(t, "text/html")
+}
@@ -63,4 +63,9 @@ func Get(ctx context.Context) string {
 		return ""
 	}
 	return value
-}
\ No newline at end of file
+}
+
+// Set returns a new context.Context with the given environment
+func Set(ctx context.Context, environment string) context.Context {
+	return context.WithValue(ctx, environmentKey, environment)
+}
@@ -1,6 +1,7 @@
 package environment_test
 
 import (
+	"context"
 	"github.com/d-velop/dvelop-sdk-go/environment"
 	"net/http"
 	"net/http/httptest"
@@ -59,3 +60,10 @@ func (spy *handlerSpy) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	spy.hasBeenCalled = true
 	spy.environment = environment.Get(r.Context())
 }
+
+func TestEnvironmentOnContext_Set_ReturnsContextWithEnvironment(t *testing.T) {
+	ctx := environment.Set(context.Background(), "dev")
+	if e := environment.Get(ctx); e != "dev" {
+		t.Errorf("got wrong environment from context: got %v want %v", e, "dev")
+	}
+}
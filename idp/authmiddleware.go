@@ -8,9 +8,9 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
-	"strconv"
 	"strings"
 
+	"github.com/d-velop/dvelop-sdk-go/contentnegotiation/mediatype"
 	"github.com/d-velop/dvelop-sdk-go/idp/scim"
 )
 
@@ -18,12 +18,16 @@ type contextKey string
 
 const principalKey = contextKey("Principal")
 const authSessionIdKey = contextKey("AuthSessionId")
+const authSessionIdCookie = "AuthSessionId"
 
 // Authenticate authenticates the user using the IdentityProvider-App
 //
 // If the user is already logged in the credentials of the user are taken from the http request.
 // Otherwise the request is redirected to the IdentityProvider for authentication and redirected back to
 // the resource which has been originally invoked.
+// The redirect is only used for navigations of a browser. Requests which are issued by scripts (e.g. fetch or
+// XMLHttpRequest calls of a single page application), which don't accept text/html or which are not GET or HEAD
+// requests are answered with 401 - unauthorized instead.
 // If the user is logged in successfully information about the user (principal) and the authSession can be
 // taken from the context.
 // The parameter allowExternalValidation determines if the handler accepts external users. External users
@@ -71,11 +75,11 @@ func Authenticate(validator Validator, getSystemBaseUriFromCtx, getTenantIdFromC
 				return
 			}
 			if authSessionId == "" {
-				if isTextHtmlAccepted(req.Header.Get("Accept")) && req.Method == http.MethodGet || req.Method == http.MethodHead {
+				if isLoginRedirectAppropriate(req) {
 					redirectToIdpLogin(rw, req)
 				} else {
-					rw.WriteHeader(http.StatusUnauthorized)
 					rw.Header().Set("WWW-Authenticate", "Bearer")
+					rw.WriteHeader(http.StatusUnauthorized)
 				}
 				return
 			}
@@ -98,11 +102,11 @@ func Authenticate(validator Validator, getSystemBaseUriFromCtx, getTenantIdFromC
 				return
 			}
 			if principal == nil {
-				if isTextHtmlAccepted(req.Header.Get("Accept")) && req.Method == http.MethodGet || req.Method == http.MethodHead {
+				if isLoginRedirectAppropriate(req) {
 					redirectToIdpLogin(rw, req)
 				} else {
-					rw.WriteHeader(http.StatusUnauthorized)
 					rw.Header().Set("WWW-Authenticate", "Bearer")
+					rw.WriteHeader(http.StatusUnauthorized)
 				}
 				return
 			}
@@ -111,8 +115,8 @@ func Authenticate(validator Validator, getSystemBaseUriFromCtx, getTenantIdFromC
 				http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 				return
 			}
-			ctx = context.WithValue(ctx, authSessionIdKey, authSessionId)
-			ctx = context.WithValue(ctx, principalKey, *principal)
+			ctx = SetAuthSessionId(ctx, authSessionId)
+			ctx = SetPrincipal(ctx, *principal)
 			next.ServeHTTP(rw, req.WithContext(ctx))
 		})
 	}
@@ -135,32 +139,26 @@ func redirectToIdpLogin(rw http.ResponseWriter, req *http.Request) {
 	rw.WriteHeader(http.StatusFound)
 }
 
-func isTextHtmlAccepted(header string) bool {
-	trimmedHeader := strings.TrimSpace(header)
-	if trimmedHeader == "" {
-		return true
+// isLoginRedirectAppropriate decides if an unauthenticated request should be redirected to the login page
+// of the IdentityProvider-App or answered with 401.
+//
+// Only navigations of a browser are redirected, that is GET or HEAD requests which accept text/html and which
+// are not issued by scripts. Scripts are detected by the fetch metadata header Sec-Fetch-Mode and the
+// X-Requested-With header which is set by many javascript libraries for XMLHttpRequests.
+func isLoginRedirectAppropriate(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
 	}
-	acceptableTypes := strings.Split(trimmedHeader, ",")
-	for _, a := range acceptableTypes {
-		parts := strings.SplitN(a, ";", 2)
-		t := strings.TrimSpace(parts[0])
-		if t == "*/*" || t == "text/*" {
-			t = "text/html"
-		}
-		q := 1.0
-		if len(parts) == 2 && len(parts[1]) > 2 {
-			qPart := strings.TrimSpace(parts[1][3:])
-			var err error
-			q, err = strconv.ParseFloat(qPart, 64)
-			if err != nil {
-				q = 0
-			}
-		}
-		if (t == "text/html") && (q > 0.0) {
-			return true
-		}
+	// cf. https://www.w3.org/TR/fetch-metadata/#sec-fetch-mode-header
+	if mode := strings.ToLower(req.Header.Get("Sec-Fetch-Mode")); mode != "" && mode != "navigate" && mode != "nested-navigate" {
+		return false
 	}
-	return false
+	if strings.EqualFold(req.Header.Get("X-Requested-With"), "XMLHttpRequest") {
+		return false
+	}
+	acceptHeader := strings.TrimSpace(strings.Join(req.Header["Accept"], ","))
+	_, err := mediatype.Negotiate(acceptHeader, []string{"text/html"})
+	return err == nil
 }
 
 var bearerTokenRegex = regexp.MustCompile("^(?i)bearer (.*)$") // cf. https://regex101.com/
@@ -171,9 +169,8 @@ func authSessionIdFromRequest(ctx context.Context, req *http.Request, logInfo fu
 	if matches != nil {
 		return matches[1], nil
 	}
-	const authSessionId = "AuthSessionId"
 	for _, cookie := range req.Cookies() {
-		if cookie.Name == authSessionId {
+		if cookie.Name == authSessionIdCookie {
 			// cookie is URL encoded cf. https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Set-Cookie
 			value, err := url.QueryUnescape(cookie.Value)
 			if err != nil {
@@ -201,3 +198,13 @@ func AuthSessionIdFromCtx(ctx context.Context) (string, error) {
 	}
 	return authSessionId, nil
 }
+
+// SetPrincipal returns a new context.Context with the given principal
+func SetPrincipal(ctx context.Context, principal scim.Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// SetAuthSessionId returns a new context.Context with the given authSessionId
+func SetAuthSessionId(ctx context.Context, authSessionId string) context.Context {
+	return context.WithValue(ctx, authSessionIdKey, authSessionId)
+}
@@ -33,7 +33,7 @@ var principals = map[string]scim.Principal{
 const validExternalAuthSessionId = "1XGxJeb0q+/fS8biFi8FE7TovJPPEPyzlDxT6bh5p5pHA/x7CEi1w9egVhEMz8IWhrtvJRFnkSqJnLr61cOKf/i5eWuu7Duh+OTtTjMOt9w=&Cnh4NNU90wH_OVlgbzbdZOEu1aSuPlbUctiCdYTonZ3Ap_Zd3bVL79I-dPdHf4OOgO8NKEdqyLsqc8RhAOreXgJqXuqsreeI"
 
 var externalPrincipals = map[string]scim.Principal{
-	validExternalAuthSessionId: {Emails: []scim.UserValue{{"info@d-velop.de"}}, Groups: []scim.UserGroup{{Value: "3E093BE5-CCCE-435D-99F8-544656B98681"}}},
+	validExternalAuthSessionId: {Emails: []scim.UserValue{{Value: "info@d-velop.de"}}, Groups: []scim.UserGroup{{Value: "3E093BE5-CCCE-435D-99F8-544656B98681"}}},
 }
 
 func TestNoAuthSessionId(t *testing.T) {
@@ -55,6 +55,21 @@ func TestNoAuthSessionId(t *testing.T) {
 		"AndHeadRequestAndHtmlAccepted_Middleware_RedirectsToIdp": {
 			method: http.MethodHead, headers: map[string]string{"Accept": "text/html"}, url: "/a/b?q1=x&q2=1",
 			want: result{StatusCode: http.StatusFound, Headers: http.Header{"Location": {"/identityprovider/login?redirect=" + url.QueryEscape("/a/b?q1=x&q2=1")}}}},
+		"AndHeadRequestAndHtmlNotAccepted_Middleware_ReturnsStatus401AndWWW-AuthenticateBearerHeader": {
+			method: http.MethodHead, headers: map[string]string{"Accept": "application/json"}, url: "/a/b?q1=x&q2=1",
+			want: result{StatusCode: http.StatusUnauthorized, Headers: http.Header{"Www-Authenticate": {"Bearer"}}}},
+		"AndGetRequestAndHtmlAcceptedAndSecFetchModeNavigate_Middleware_RedirectsToIdp": {
+			method: http.MethodGet, headers: map[string]string{"Accept": "text/html", "Sec-Fetch-Mode": "navigate"}, url: "/a/b?q1=x&q2=1",
+			want: result{StatusCode: http.StatusFound, Headers: http.Header{"Location": {"/identityprovider/login?redirect=" + url.QueryEscape("/a/b?q1=x&q2=1")}}}},
+		"AndGetRequestAndAnythingAcceptedAndSecFetchModeCors_Middleware_ReturnsStatus401AndWWW-AuthenticateBearerHeader": {
+			method: http.MethodGet, headers: map[string]string{"Accept": "*/*", "Sec-Fetch-Mode": "cors"}, url: "/a/b?q1=x&q2=1",
+			want: result{StatusCode: http.StatusUnauthorized, Headers: http.Header{"Www-Authenticate": {"Bearer"}}}},
+		"AndGetRequestAndAnythingAcceptedAndSecFetchModeSameOrigin_Middleware_ReturnsStatus401AndWWW-AuthenticateBearerHeader": {
+			method: http.MethodGet, headers: map[string]string{"Accept": "*/*", "Sec-Fetch-Mode": "same-origin"}, url: "/a/b?q1=x&q2=1",
+			want: result{StatusCode: http.StatusUnauthorized, Headers: http.Header{"Www-Authenticate": {"Bearer"}}}},
+		"AndGetRequestAndHtmlAcceptedAndXRequestedWith_Middleware_ReturnsStatus401AndWWW-AuthenticateBearerHeader": {
+			method: http.MethodGet, headers: map[string]string{"Accept": "text/html", "X-Requested-With": "XMLHttpRequest"}, url: "/a/b?q1=x&q2=1",
+			want: result{StatusCode: http.StatusUnauthorized, Headers: http.Header{"Www-Authenticate": {"Bearer"}}}},
 		"ButBasicAuthorizationAndGetRequestAndHtmlAccepted_Middleware_RedirectsToIdp": {
 			method: http.MethodGet, headers: map[string]string{"Authorization": "Basic adadbk", "Accept": "text/html"}, url: "/a/b?q1=x&q2=1",
 			want: result{StatusCode: http.StatusFound, Headers: http.Header{"Location": {"/identityprovider/login?redirect=" + url.QueryEscape("/a/b?q1=x&q2=1")}}}},
@@ -458,7 +473,7 @@ func TestRequestAsExternalUserAndExternalUserValidationIsNotAllowed_ReturnsStatu
 	const authSessionId = "hXGxJeb0q+/fS8biFi8FE7TovJPPEPyzlDxT6bh5p5pHA/x7CEi1w9egVhEMz8IWhrtvJRFnkSqJnLr61cOKf/i5eWuu7Duh+OTtTjMOt9w=&Bnh4NNU90wH_OVlgbzbdZOEu1aSuPlbUctiCdYTonZ3Ap_Zd3bVL79I-dPdHf4OOgO8NKEdqyLsqc8RhAOreXgJqXuqsreeI"
 	req.Header.Set("Authorization", "Bearer "+authSessionId)
 	handlerSpy := handlerSpy{}
-	idpStub := test.NewIdpValidateStub(nil, map[string]scim.Principal{authSessionId: {Emails: []scim.UserValue{{"info@d-velop.de"}}, Groups: []scim.UserGroup{{Value: "3E093BE5-CCCE-435D-99F8-544656B98681"}}}})
+	idpStub := test.NewIdpValidateStub(nil, map[string]scim.Principal{authSessionId: {Emails: []scim.UserValue{{Value: "info@d-velop.de"}}, Groups: []scim.UserGroup{{Value: "3E093BE5-CCCE-435D-99F8-544656B98681"}}}})
 	defer idpStub.Close()
 	spy := responseSpy{httptest.NewRecorder()}
 
@@ -477,7 +492,7 @@ func TestRequestAsExternalUserAndExternalValidationIsAllowed_PopulatesContextWit
 		t.Fatal(err)
 	}
 	const authSessionId = "1XGxJeb0q+/fS8biFi8FE7TovJPPEPyzlDxT6bh5p5pHA/x7CEi1w9egVhEMz8IWhrtvJRFnkSqJnLr61cOKf/i5eWuu7Duh+OTtTjMOt9w=&Bnh4NNU90wH_OVlgbzbdZOEu1aSuPlbUctiCdYTonZ3Ap_Zd3bVL79I-dPdHf4OOgO8NKEdqyLsqc8RhAOreXgJqXuqsreeI"
-	principal := scim.Principal{Emails: []scim.UserValue{{"info@d-velop.de"}}, Groups: []scim.UserGroup{{Value: "3E093BE5-CCCE-435D-99F8-544656B98681"}}}
+	principal := scim.Principal{Emails: []scim.UserValue{{Value: "info@d-velop.de"}}, Groups: []scim.UserGroup{{Value: "3E093BE5-CCCE-435D-99F8-544656B98681"}}}
 	req.Header.Set("Authorization", "Bearer "+authSessionId)
 	handlerSpy := new(handlerSpy)
 	idpStub := test.NewIdpValidateStub(nil, map[string]scim.Principal{authSessionId: principal})
@@ -567,6 +582,12 @@ func TestNoAuthSessionIdAndGetRequestAndAcceptHeaderIs(t *testing.T) {
 		{"application/json; q=1.0, text/html; q=0", false},
 		{"application/json; q=0.9, text/html; q=1.0", true},
 		{"application/json; q=1.0, text/html; q=0.", false}, // broken header
+		{"text/html;q=0", false},
+		{"text/html; Q=0.5", true},
+		{"text/html; level=1; q=0", false},
+		{"application/json, */*; q=0", false},
+		{"application/json,, text/html", true},
+		{"text/html; q=abc", false},
 	}
 
 	for _, testCase := range testCases {
@@ -612,6 +633,21 @@ func TestNoAuthSessionIdAndGetRequestAndAcceptHeaderIs(t *testing.T) {
 	}
 }
 
+func TestNoPrincipalOnContext_SetPrincipal_ReturnsContextWithPrincipal(t *testing.T) {
+	principal := scim.Principal{Id: "9bbbf1b6-017a-449a-ad5f-9723d28223e1"}
+	ctx := idp.SetPrincipal(context.Background(), principal)
+	if p, _ := idp.PrincipalFromCtx(ctx); !reflect.DeepEqual(p, principal) {
+		t.Errorf("got wrong principal from context: got %v want %v", p, principal)
+	}
+}
+
+func TestNoAuthSessionIdOnContext_SetAuthSessionId_ReturnsContextWithAuthSessionId(t *testing.T) {
+	ctx := idp.SetAuthSessionId(context.Background(), validAuthSessionId)
+	if authSessionId, _ := idp.AuthSessionIdFromCtx(ctx); authSessionId != validAuthSessionId {
+		t.Errorf("got wrong authSessionId from context: got %v want %v", authSessionId, validAuthSessionId)
+	}
+}
+
 type handlerSpy struct {
 	authSessionId string
 	principal     scim.Principal
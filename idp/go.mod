@@ -1,8 +1,12 @@
 module github.com/d-velop/dvelop-sdk-go/idp
 
 require (
+	github.com/d-velop/dvelop-sdk-go/contentnegotiation v0.0.0-20261018204817-71134894e9c8
 	github.com/google/go-cmp v0.3.1
 	github.com/patrickmn/go-cache v2.1.0+incompatible
 )
 
+// local development only, replace directives are ignored when this module is required by other modules
+replace github.com/d-velop/dvelop-sdk-go/contentnegotiation => ../contentnegotiation
+
 go 1.13
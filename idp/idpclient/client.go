@@ -34,6 +34,13 @@ type Cache interface {
 	Set(key string, item interface{}, cacheDuration time.Duration)
 }
 
+// cacheDeleter is implemented by caches which support the removal of items.
+// If the principalCache implements it, cached principals are removed if their session is terminated.
+type cacheDeleter interface {
+	// Delete an item from the cache. Does nothing if the key is not in the cache.
+	Delete(key string)
+}
+
 type Option func(*client) error
 
 // HttpClient explicitly sets the http.Client which should be used to make
@@ -45,6 +52,10 @@ func HttpClient(h *http.Client) Option {
 	}
 }
 
+// PrincipalCache explicitly sets the Cache which is used to cache validated principals.
+//
+// If the Cache additionally implements a method Delete(key string), principals are removed from the
+// cache as soon as their session is terminated by TerminateSession.
 func PrincipalCache(pc Cache) Option {
 	return func(c *client) error {
 		c.principalCache = pc
@@ -117,7 +128,7 @@ Inspect the scim.Principal after a successful validation to distinguish external
 (cf. documentation of scim.Principal for further information).
 */
 func (c *client) Validate(ctx context.Context, systemBaseUri string, tenantId string, authSessionId string) (*scim.Principal, error) {
-	cacheKey := fmt.Sprintf("%s/%s", tenantId, authSessionId)
+	cacheKey := principalCacheKey(tenantId, authSessionId)
 	co, found := c.principalCache.Get(cacheKey)
 	if found {
 		p := co.(scim.Principal)
@@ -198,6 +209,44 @@ func (c *client) GetPrincipalById(ctx context.Context, systemBaseUri string, ten
 	}
 }
 
+/*
+TerminateSession terminates the session specified by authSessionId for the tenant specified by systemBaseUri and tenantId.
+
+Afterwards the authSessionId is no longer valid. A principal which has been cached for the authSessionId by Validate
+is removed from the cache, if the cache supports the removal of items (cf. PrincipalCache).
+Terminating a session which is already invalid is not an error.
+
+An error is returned if something unexpected occurred.
+The returned error will be of type *url.Error if the remote call to the IdentityProvider-App failed due to a network
+connectivity problem or a timout.
+*/
+func (c *client) TerminateSession(ctx context.Context, systemBaseUri string, tenantId string, authSessionId string) error {
+	if d, ok := c.principalCache.(cacheDeleter); ok {
+		d.Delete(principalCacheKey(tenantId, authSessionId))
+	}
+
+	endpoint := "/identityprovider/logout"
+	resp, doErr := c.httpGet(ctx, systemBaseUri, authSessionId, endpoint)
+	if doErr != nil {
+		return fmt.Errorf("error calling http GET on '%s' because: %w", endpoint, doErr)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300, resp.StatusCode == http.StatusUnauthorized:
+		_, _ = ioutil.ReadAll(resp.Body)
+		return nil
+	default:
+		responseMsg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected error. Identityprovider '%s' returned HTTP-Statuscode '%d' and message '%s'",
+			resp.Request.URL, resp.StatusCode, responseMsg)
+	}
+}
+
+func principalCacheKey(tenantId string, authSessionId string) string {
+	return fmt.Sprintf("%s/%s", tenantId, authSessionId)
+}
+
 func (c *client) httpGet(ctx context.Context, systemBaseUri string, authSessionId string, absolutePath string) (*http.Response, error) {
 	baseUri, baseParseErr := url.Parse(systemBaseUri)
 	if baseParseErr != nil {
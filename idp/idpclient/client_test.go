@@ -45,7 +45,7 @@ var principals = map[string]scim.Principal{
 const validExternalAuthSessionId = "1XGxJeb0q+/fS8biFi8FE7TovJPPEPyzlDxT6bh5p5pHA/x7CEi1w9egVhEMz8IWhrtvJRFnkSqJnLr61cOKf/i5eWuu7Duh+OTtTjMOt9w=&Cnh4NNU90wH_OVlgbzbdZOEu1aSuPlbUctiCdYTonZ3Ap_Zd3bVL79I-dPdHf4OOgO8NKEdqyLsqc8RhAOreXgJqXuqsreeI"
 
 var externalPrincipals = map[string]scim.Principal{
-	validExternalAuthSessionId: {Emails: []scim.UserValue{{"info@d-velop.de"}}, Groups: []scim.UserGroup{{Value: "3E093BE5-CCCE-435D-99F8-544656B98681"}}},
+	validExternalAuthSessionId: {Emails: []scim.UserValue{{Value: "info@d-velop.de"}}, Groups: []scim.UserGroup{{Value: "3E093BE5-CCCE-435D-99F8-544656B98681"}}},
 }
 
 const invalidAuthSessionId = "2XGxJeb0q+/fS8biFi8FE7TovJPPEPyzlDxT6bh5p5pHA/x7CEi1w9egVhEMz8IWhrtvJRFnkSqJnLr61cOKf/i5eWuu7Duh+OTtTjMOt9w=&Dnh4NNU90wH_OVlgbzbdZOEu1aSuPlbUctiCdYTonZ3Ap_Zd3bVL79I-dPdHf4OOgO8NKEdqyLsqc8RhAOreXgJqXuqsreeI"
@@ -456,3 +456,50 @@ func TestNonExplicitHandledReturnCodeWithEmptyResponseMsg_Validate_ReturnsError(
 		t.Error("expects an error of the idp")
 	}
 }
+
+func TestValidSession_TerminateSession_InvalidatesSession(t *testing.T) {
+	fake := test.NewFakeIdp()
+	defer fake.Close()
+	fake.AddUser(scim.Principal{Id: "9bbbf1b6-017a-449a-ad5f-9723d28223e1"})
+	authSessionId, _ := fake.Login("9bbbf1b6-017a-449a-ad5f-9723d28223e1")
+
+	err := defaultClient.TerminateSession(context.Background(), fake.URL, "1", authSessionId)
+
+	if err != nil {
+		t.Error(err)
+	}
+	if fake.HasSession(authSessionId) {
+		t.Error("session should have been terminated")
+	}
+}
+
+func TestPrincipalIsCached_TerminateSession_RemovesCachedPrincipal(t *testing.T) {
+	fake := test.NewFakeIdp()
+	defer fake.Close()
+	fake.AddUser(scim.Principal{Id: "9bbbf1b6-017a-449a-ad5f-9723d28223e1"})
+	authSessionId, _ := fake.Login("9bbbf1b6-017a-449a-ad5f-9723d28223e1")
+	client, _ := idpclient.New()
+	_, _ = client.Validate(context.Background(), fake.URL, "1", authSessionId)
+
+	_ = client.TerminateSession(context.Background(), fake.URL, "1", authSessionId)
+	p, err := client.Validate(context.Background(), fake.URL, "1", authSessionId)
+
+	if err != nil {
+		t.Error(err)
+	}
+	if p != nil {
+		t.Errorf("expected nil principal after terminated session but got %v", p)
+	}
+}
+
+func TestIdpReturnsStatus500_TerminateSession_ReturnsError(t *testing.T) {
+	fake := test.NewFakeIdp()
+	defer fake.Close()
+	fake.FailWith(http.StatusInternalServerError)
+
+	err := defaultClient.TerminateSession(context.Background(), fake.URL, "1", validAuthSessionId)
+
+	if err == nil {
+		t.Error("expected error but got nil")
+	}
+}
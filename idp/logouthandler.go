@@ -0,0 +1,88 @@
+package idp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SessionTerminator is an interface representing the ability to terminate a session
+type SessionTerminator interface {
+	// TerminateSession terminates the session specified by authSessionId for the tenant specified by systemBaseUri and tenantId.
+	//
+	// Afterwards the authSessionId is no longer valid, that is Validate doesn't return a principal for it anymore.
+	//
+	// An error is returned if something unexpected occurred.
+	TerminateSession(ctx context.Context, systemBaseUri string, tenantId string, authSessionId string) error
+}
+
+// Logout returns a http.Handler which signs out the current user.
+//
+// Only POST requests are accepted, other methods are answered with status 405. Otherwise any page could sign out the user
+// by embedding the logout URL e.g. as image (logout CSRF). So use a form like
+//	<form method="post" action="/logout?redirect=/app/start"><button>Logout</button></form>
+//
+// The handler terminates the session of the user at the IdentityProvider-App, which also invalidates the cached
+// principal, clears the AuthSessionId cookie and redirects with status 303 to the logout of the IdentityProvider-App.
+// After the logout the IdentityProvider-App redirects to the relative URL given by the query parameter 'redirect'
+// or to '/' if the parameter is missing or no relative URL.
+// If the session can't be terminated the error is logged but the user is signed out of the browser nevertheless.
+//
+// Example:
+//	func main() {
+//		idpClient,err := idpclient.New()
+//		if err != nil {
+//			// error handling
+//		}
+//		mux := http.NewServeMux()
+//		mux.Handle("/logout", idp.Logout(idpClient, tenant.SystemBaseUriFromCtx, tenant.IdFromCtx, logError, logInfo))
+//	}
+func Logout(terminator SessionTerminator, getSystemBaseUriFromCtx, getTenantIdFromCtx func(ctx context.Context) (string, error), logError, logInfo func(ctx context.Context, message string)) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			rw.Header().Set("Allow", http.MethodPost)
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		ctx := req.Context()
+		authSessionId, aErr := authSessionIdFromRequest(ctx, req, logInfo)
+		if aErr != nil {
+			logInfo(ctx, fmt.Sprintf("can't terminate session because: %v\n", aErr))
+		}
+		if authSessionId != "" {
+			if err := terminateSession(ctx, terminator, getSystemBaseUriFromCtx, getTenantIdFromCtx, authSessionId); err != nil {
+				logError(ctx, fmt.Sprintf("error terminating session because: %v\n", err))
+			}
+		}
+		http.SetCookie(rw, &http.Cookie{Name: authSessionIdCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, Secure: true})
+		redirectToIdpLogout(rw, req)
+	})
+}
+
+func terminateSession(ctx context.Context, terminator SessionTerminator, getSystemBaseUriFromCtx, getTenantIdFromCtx func(ctx context.Context) (string, error), authSessionId string) error {
+	systemBaseUri, gSBErr := getSystemBaseUriFromCtx(ctx)
+	if gSBErr != nil {
+		return fmt.Errorf("error reading SystemBaseUri from context because: %v", gSBErr)
+	}
+	tenantId, gTErr := getTenantIdFromCtx(ctx)
+	if gTErr != nil {
+		return fmt.Errorf("error reading TenandId from context because: %v", gTErr)
+	}
+	return terminator.TerminateSession(ctx, systemBaseUri, tenantId, authSessionId)
+}
+
+func redirectToIdpLogout(rw http.ResponseWriter, req *http.Request) {
+	const redirectionBase = "/identityprovider/logout?redirect="
+	rw.Header().Set("Location", redirectionBase+url.QueryEscape(localRedirectTarget(req.URL.Query().Get("redirect"))))
+	rw.WriteHeader(http.StatusSeeOther)
+}
+
+// localRedirectTarget prevents open redirects by accepting relative URLs only
+func localRedirectTarget(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
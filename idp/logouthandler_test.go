@@ -0,0 +1,150 @@
+package idp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/idp"
+	"github.com/d-velop/dvelop-sdk-go/idp/idpclient"
+	"github.com/d-velop/dvelop-sdk-go/idp/scim"
+	"github.com/d-velop/dvelop-sdk-go/idp/test"
+)
+
+func TestLogout(t *testing.T) {
+	testcases := map[string]struct {
+		url          string
+		wantLocation string
+	}{
+		// read function name and testCase name as one sentence. e.g. TestLogoutWithoutRedirect_Handler_RedirectsToIdpLogoutWithRoot
+		"WithoutRedirect_Handler_RedirectsToIdpLogoutWithRoot": {
+			url: "/logout", wantLocation: "/identityprovider/logout?redirect=" + url.QueryEscape("/")},
+		"WithRelativeRedirect_Handler_RedirectsToIdpLogoutWithRedirect": {
+			url: "/logout?redirect=" + url.QueryEscape("/app/start?x=1"), wantLocation: "/identityprovider/logout?redirect=" + url.QueryEscape("/app/start?x=1")},
+		"WithAbsoluteRedirect_Handler_RedirectsToIdpLogoutWithRoot": {
+			url: "/logout?redirect=" + url.QueryEscape("https://evil.example/"), wantLocation: "/identityprovider/logout?redirect=" + url.QueryEscape("/")},
+		"WithSchemeRelativeRedirect_Handler_RedirectsToIdpLogoutWithRoot": {
+			url: "/logout?redirect=" + url.QueryEscape("//evil.example/"), wantLocation: "/identityprovider/logout?redirect=" + url.QueryEscape("/")},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			fake := test.NewFakeIdp()
+			defer fake.Close()
+			fake.AddUser(scim.Principal{Id: "9bbbf1b6-017a-449a-ad5f-9723d28223e1"})
+			authSessionId, _ := fake.Login("9bbbf1b6-017a-449a-ad5f-9723d28223e1")
+			req := httptest.NewRequest(http.MethodPost, tc.url, nil)
+			req.AddCookie(&http.Cookie{Name: "AuthSessionId", Value: url.QueryEscape(authSessionId)})
+			spy := responseSpy{httptest.NewRecorder()}
+
+			idp.Logout(idpClient, returnFromCtx(fake.URL), returnFromCtx("1"), log, log).ServeHTTP(spy, req)
+
+			if err := spy.assertStatusCodeIs(http.StatusSeeOther); err != nil {
+				t.Error(err)
+			}
+			if err := spy.assertHeadersAre(map[string]string{"Location": tc.wantLocation}); err != nil {
+				t.Error(err)
+			}
+			if fake.HasSession(authSessionId) {
+				t.Error("session should have been terminated")
+			}
+		})
+	}
+}
+
+func TestLogout_Handler_ClearsAuthSessionIdCookie(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+validAuthSessionId)
+	rec := httptest.NewRecorder()
+
+	idp.Logout(&sessionTerminatorSpy{}, returnFromCtx("https://sample.example.com"), returnFromCtx("1"), log, log).ServeHTTP(rec, req)
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "AuthSessionId" || cookies[0].MaxAge >= 0 {
+		t.Errorf("expected expired AuthSessionId cookie but got %v", cookies)
+	}
+}
+
+func TestValidatedPrincipalIsCached_Logout_InvalidatesCachedPrincipal(t *testing.T) {
+	fake := test.NewFakeIdp()
+	defer fake.Close()
+	fake.AddUser(scim.Principal{Id: "9bbbf1b6-017a-449a-ad5f-9723d28223e1"})
+	authSessionId, _ := fake.Login("9bbbf1b6-017a-449a-ad5f-9723d28223e1")
+	client, _ := idpclient.New()
+	if p, _ := client.Validate(context.Background(), fake.URL, "1", authSessionId); p == nil {
+		t.Fatal("principal should be valid before logout")
+	}
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+authSessionId)
+
+	idp.Logout(client, returnFromCtx(fake.URL), returnFromCtx("1"), log, log).ServeHTTP(httptest.NewRecorder(), req)
+
+	if p, _ := client.Validate(context.Background(), fake.URL, "1", authSessionId); p != nil {
+		t.Errorf("principal should be invalid after logout but got %v", p)
+	}
+}
+
+func TestTerminateSessionReturnsError_Logout_RedirectsToIdpLogout(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+validAuthSessionId)
+	spy := responseSpy{httptest.NewRecorder()}
+	terminator := &sessionTerminatorSpy{err: errors.New("any error")}
+
+	idp.Logout(terminator, returnFromCtx("https://sample.example.com"), returnFromCtx("1"), log, log).ServeHTTP(spy, req)
+
+	if err := spy.assertStatusCodeIs(http.StatusSeeOther); err != nil {
+		t.Error(err)
+	}
+	if terminator.authSessionId != validAuthSessionId {
+		t.Errorf("wrong authSessionId terminated: got %v want %v", terminator.authSessionId, validAuthSessionId)
+	}
+}
+
+func TestNoAuthSessionId_Logout_DoesntTerminateSession(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	spy := responseSpy{httptest.NewRecorder()}
+	terminator := &sessionTerminatorSpy{}
+
+	idp.Logout(terminator, returnFromCtx("https://sample.example.com"), returnFromCtx("1"), log, log).ServeHTTP(spy, req)
+
+	if err := spy.assertStatusCodeIs(http.StatusSeeOther); err != nil {
+		t.Error(err)
+	}
+	if terminator.hasBeenCalled {
+		t.Error("TerminateSession should not have been called")
+	}
+}
+
+func TestGetRequest_Logout_ReturnsMethodNotAllowedAndDoesntTerminateSession(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+validAuthSessionId)
+	spy := responseSpy{httptest.NewRecorder()}
+	terminator := &sessionTerminatorSpy{}
+
+	idp.Logout(terminator, returnFromCtx("https://sample.example.com"), returnFromCtx("1"), log, log).ServeHTTP(spy, req)
+
+	if err := spy.assertStatusCodeIs(http.StatusMethodNotAllowed); err != nil {
+		t.Error(err)
+	}
+	if err := spy.assertHeadersAre(map[string]string{"Allow": http.MethodPost}); err != nil {
+		t.Error(err)
+	}
+	if terminator.hasBeenCalled {
+		t.Error("TerminateSession should not have been called")
+	}
+}
+
+type sessionTerminatorSpy struct {
+	hasBeenCalled bool
+	authSessionId string
+	err           error
+}
+
+func (spy *sessionTerminatorSpy) TerminateSession(ctx context.Context, systemBaseUri string, tenantId string, authSessionId string) error {
+	spy.hasBeenCalled = true
+	spy.authSessionId = authSessionId
+	return spy.err
+}
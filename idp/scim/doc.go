@@ -2,4 +2,9 @@
 // Simple Cloud Identity Management (SCIM) core schema 1.0
 //
 // cf. http://www.simplecloud.info/specs/draft-scim-core-schema-00.html#schema
+//
+// Attributes which have been added by the SCIM 2.0 user schema and its
+// extensions are supported as well.
+//
+// cf. https://tools.ietf.org/html/rfc7643#section-4
 package scim
@@ -0,0 +1,43 @@
+package scim
+
+import (
+	"encoding/json"
+)
+
+// Group represents a group of users.
+//
+// It complies to the SCIM Group Schema.
+// cf. http://www.simplecloud.info/specs/draft-scim-core-schema-00.html#group-resource
+type Group struct {
+	// ID is a unique identifier for the SCIM Resource as defined by the Service Provider. REQUIRED and READ-ONLY.
+	Id string `json:"id"`
+
+	// ExternalID is a unique identifier for the Resource as defined by the Service Consumer.
+	ExternalId string `json:"externalId,omitempty"`
+
+	// DisplayName is a human readable name for the Group. REQUIRED.
+	DisplayName string `json:"displayName"`
+
+	// Members contains a list of members of the Group.
+	//
+	// Canonical Types "User" and "Group" are READ-ONLY. The value must be the "id" of a SCIM resource, either a User, or a Group. The intention of the Group type is to allow the Service Provider to support nested Groups.
+	Members []GroupMember `json:"members,omitempty"`
+
+	// Meta contains the resource metadata like the resource type and the time of creation and last modification. READ-ONLY.
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+func (g Group) String() string {
+	b, _ := json.Marshal(g)
+	return string(b)
+}
+
+// GroupMember references a member of a group.
+type GroupMember struct {
+	// Value is the id of the SCIM resource representing the member.
+	Value string `json:"value"`
+	// Display is a human readable name of the member, primarily used for display purposes.
+	Display string `json:"display,omitempty"`
+	// Type is the type of the member; e.g., "User" or "Group".
+	Type string `json:"type,omitempty"`
+}
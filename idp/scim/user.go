@@ -2,6 +2,8 @@ package scim
 
 import (
 	"encoding/json"
+	"reflect"
+	"strings"
 )
 
 // Principal represents a user.
@@ -59,13 +61,194 @@ type Principal struct {
 	//
 	// The values are meant to enable expression of common group or role based access control models, although no explicit authorization model is defined. It is intended that the semantics of group membership and any behavior or authorization granted as a result of membership are defined by the Service Provider. The Canonical types "direct" and "indirect" are defined to describe how the group membership was derived. Â Direct group membership indicates the User is directly associated with the group and SHOULD indicate that Consumers may modify membership through the Group Resource. Â Indirect membership indicates User membership is transitive or dynamic and implies that Consumers cannot modify indirect group membership through the Group resource but MAY modify direct group membership through the Group resource which MAY influence indirect memberships. Â If the SCIM Service Provider exposes a Group resource, the value MUST be the "id" attribute of the corresponding Group resources to which the user belongs. Since this attribute is read-only, group membership changes MUST be applied via the Group Resource. READ-ONLY.
 	Groups []UserGroup `json:"groups"`
+
+	// Schemas contains the URIs of the SCIM schemas used to define the attributes present in the current JSON structure.
+	//
+	// Each String value must be a unique URI. Apart from the core schema it lists the schema extensions like EnterpriseUserSchema which are present in the representation of the user.
+	Schemas []string `json:"schemas,omitempty"`
+
+	// Active indicates the User's administrative status.
+	//
+	// The value is nil if the Service Provider didn't transmit the attribute. Use IsActive() to evaluate the value.
+	Active *bool `json:"active,omitempty"`
+
+	// PreferredLanguage indicates the User's preferred written or spoken language.
+	//
+	// Generally used for selecting a localized User interface. Valid values are concatenation of the ISO 639-1 two letter language code, an underscore, and the ISO 3166-1 2 letter country code; e.g., 'en_US' specifies the language English and country US.
+	PreferredLanguage string `json:"preferredLanguage,omitempty"`
+
+	// Locale is used to indicate the User's default location for purposes of localizing items such as currency, date time format, numerical representations, etc.
+	//
+	// A locale value is a concatenation of the ISO 639-1 two letter language code, an underscore, and the ISO 3166-1 2 letter country code; e.g., 'en_US' specifies the language English and country US.
+	Locale string `json:"locale,omitempty"`
+
+	// Timezone is the User's time zone in the "Olson" timezone database format; e.g.,'America/Los_Angeles'.
+	Timezone string `json:"timezone,omitempty"`
+
+	// Meta contains the resource metadata like the resource type and the time of creation and last modification. READ-ONLY.
+	Meta *Meta `json:"meta,omitempty"`
+
+	// EnterpriseUser contains the attributes of the enterprise user schema extension.
+	//
+	// The value is nil if the representation of the user doesn't contain the extension.
+	EnterpriseUser *EnterpriseUser `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+
+	// Extensions contains all attributes which are not part of the schemas modeled by this type.
+	//
+	// These are usually further schema extensions like the d.velop specific ones, which are keyed by their schema URI.
+	// The values are kept as raw JSON so they are preserved if the principal is serialized again.
+	// Use Extension() to decode a particular extension.
+	Extensions map[string]json.RawMessage `json:"-"`
 }
 
+const (
+	// CoreUserSchema is the URI of the SCIM core user schema
+	CoreUserSchema = "urn:ietf:params:scim:schemas:core:2.0:User"
+	// EnterpriseUserSchema is the URI of the SCIM enterprise user schema extension
+	EnterpriseUserSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+)
+
 func (p Principal) String() string {
 	b, _ := json.Marshal(p)
 	return string(b)
 }
 
+// MarshalJSON customizes the JSON Representation of the Principal type so that the
+// attributes contained in Extensions are serialized as well.
+func (p Principal) MarshalJSON() ([]byte, error) {
+	type Alias Principal // type alias to prevent infinite recursion
+	b, err := json.Marshal(Alias(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(b, &attributes); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extensions {
+		if !isKnownAttribute(k) {
+			attributes[k] = v
+		}
+	}
+	return json.Marshal(attributes)
+}
+
+// UnmarshalJSON customizes the JSON Deserialization of the Principal type so that attributes
+// which are unknown to the Principal type are kept in Extensions.
+func (p *Principal) UnmarshalJSON(data []byte) error {
+	type Alias Principal // type alias to prevent infinite recursion
+	var a Alias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+	for k, v := range attributes {
+		if isKnownAttribute(k) {
+			continue
+		}
+		if a.Extensions == nil {
+			a.Extensions = map[string]json.RawMessage{}
+		}
+		a.Extensions[k] = v
+	}
+	*p = Principal(a)
+	return nil
+}
+
+// knownAttributes contains the lowercase JSON names of all attributes modeled by the Principal type
+var knownAttributes = func() map[string]bool {
+	known := map[string]bool{}
+	t := reflect.TypeOf(Principal{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			known[strings.ToLower(name)] = true
+		}
+	}
+	return known
+}()
+
+// isKnownAttribute matches case-insensitive like encoding/json does
+func isKnownAttribute(name string) bool {
+	return knownAttributes[strings.ToLower(name)]
+}
+
+// Extension decodes the attributes of the schema extension identified by schemaUri into v.
+//
+// It returns false if the principal doesn't contain the extension.
+//
+// Example:
+//	var ext struct {
+//		CostCenter string `json:"costCenter"`
+//	}
+//	found, err := p.Extension("urn:example:schemas:extension:2.0:User", &ext)
+func (p *Principal) Extension(schemaUri string, v interface{}) (bool, error) {
+	raw, found := p.Extensions[schemaUri]
+	if !found {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// SetExtension sets the attributes of the schema extension identified by schemaUri to the JSON representation of v.
+func (p *Principal) SetExtension(schemaUri string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if p.Extensions == nil {
+		p.Extensions = map[string]json.RawMessage{}
+	}
+	p.Extensions[schemaUri] = raw
+	return nil
+}
+
+// IsActive returns true, if the principal is active.
+//
+// A principal without the active attribute is considered active.
+func (p *Principal) IsActive() bool {
+	return p.Active == nil || *p.Active
+}
+
+// PrimaryEmail returns the primary e-mail address of the principal.
+//
+// If no e-mail address is marked as primary the first one is returned.
+// An empty string is returned if the principal has no e-mail address at all.
+func (p *Principal) PrimaryEmail() string {
+	return primaryValue(p.Emails)
+}
+
+// PrimaryPhoneNumber returns the primary phone number of the principal.
+//
+// If no phone number is marked as primary the first one is returned.
+// An empty string is returned if the principal has no phone number at all.
+func (p *Principal) PrimaryPhoneNumber() string {
+	return primaryValue(p.PhoneNumbers)
+}
+
+// PrimaryPhoto returns the URL of the primary photo of the principal.
+//
+// If no photo is marked as primary the first one is returned.
+// An empty string is returned if the principal has no photo at all.
+func (p *Principal) PrimaryPhoto() string {
+	return primaryValue(p.Photos)
+}
+
+func primaryValue(values []UserValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
 // IsExternal returns true, if the principal is an external user.
 //
 // External users are users which have been authenticated successfully but have not been explicitly added to the list
@@ -98,11 +281,65 @@ type UserName struct {
 	HonorificSuffix string `json:"honorificSuffix"`
 }
 
+// UserValue is a multi-valued attribute of a user like an e-mail address or a phone number.
 type UserValue struct {
+	// Value is the attribute's significant value; e.g., the e-mail address, phone number etc.
 	Value string `json:"value"`
+	// Display is a human readable name, primarily used for display purposes.
+	Display string `json:"display,omitempty"`
+	// Type is a label indicating the attribute's function; e.g., "work" or "home".
+	Type string `json:"type,omitempty"`
+	// Primary indicates the 'primary' or preferred attribute value for this attribute, e.g. the preferred mailing address or primary e-mail address.
+	//
+	// The primary attribute value 'true' MUST appear no more than once.
+	Primary bool `json:"primary,omitempty"`
 }
 
 type UserGroup struct {
 	Value   string `json:"value"`
 	Display string `json:"display"`
+	// Type is a label indicating the kind of group membership; e.g., "direct" or "indirect".
+	Type string `json:"type,omitempty"`
+}
+
+// Meta contains the metadata of a SCIM resource.
+type Meta struct {
+	// ResourceType is the name of the resource type of the resource; e.g., "User".
+	ResourceType string `json:"resourceType,omitempty"`
+	// Created is the DateTime the Resource was added to the Service Provider as xsd:dateTime.
+	Created string `json:"created,omitempty"`
+	// LastModified is the most recent DateTime the details of this Resource were updated at the Service Provider as xsd:dateTime.
+	LastModified string `json:"lastModified,omitempty"`
+	// Location is the URI of the resource being returned.
+	Location string `json:"location,omitempty"`
+	// Version is the version of the resource being returned.
+	Version string `json:"version,omitempty"`
+}
+
+// EnterpriseUser contains the attributes of the SCIM enterprise user schema extension.
+//
+// cf. https://tools.ietf.org/html/rfc7643#section-4.3
+type EnterpriseUser struct {
+	// EmployeeNumber is a string identifier, typically numeric or alphanumeric, assigned to a person, typically based on order of hire or association with an organization.
+	EmployeeNumber string `json:"employeeNumber,omitempty"`
+	// CostCenter identifies the name of a cost center.
+	CostCenter string `json:"costCenter,omitempty"`
+	// Organization identifies the name of an organization.
+	Organization string `json:"organization,omitempty"`
+	// Division identifies the name of a division.
+	Division string `json:"division,omitempty"`
+	// Department identifies the name of a department.
+	Department string `json:"department,omitempty"`
+	// Manager is the User's manager.
+	Manager *Manager `json:"manager,omitempty"`
+}
+
+// Manager references the manager of a user.
+type Manager struct {
+	// Value is the id of the SCIM resource representing the User's manager.
+	Value string `json:"value,omitempty"`
+	// Ref is the URI of the SCIM resource representing the User's manager.
+	Ref string `json:"$ref,omitempty"`
+	// DisplayName is the displayName of the User's manager. READ-ONLY.
+	DisplayName string `json:"displayName,omitempty"`
 }
@@ -66,3 +66,153 @@ func TestPrincipalIsAlsoInExternalGroup_IsExternal_IsTrue(t *testing.T) {
 		t.Errorf("Expected true for principal with groups '%v' but got false", p.Groups)
 	}
 }
+
+const fullSCIMUserJson = `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User","urn:ietf:params:scim:schemas:extension:enterprise:2.0:User","urn:example:schemas:extension:dvelop:1.0:User"],"id":"146bc69e-1edf-40f6-bf68-849906998838","userName":"d-velop\\donald","displayName":"Donald Duck","active":false,"preferredLanguage":"de_DE","locale":"de_DE","timezone":"Europe/Berlin","meta":{"resourceType":"User","created":"2020-01-23T04:56:22Z","lastModified":"2021-05-13T04:42:34Z","location":"/identityprovider/scim/users/146bc69e-1edf-40f6-bf68-849906998838"},"emails":[{"value":"donald@home.de","type":"home"},{"value":"donald.duck@entenhausen.de","type":"work","primary":true}],"phoneNumbers":[{"value":"+49 1235 9455-1234","type":"work","display":"work phone"}],"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User":{"employeeNumber":"313","department":"Money Bin","manager":{"value":"26118915-6090-4610-87e4-49d8ca9f808d","displayName":"Scrooge McDuck"}},"urn:example:schemas:extension:dvelop:1.0:User":{"costUnit":"4711","flags":[1,2]}}`
+
+func TestFullSCIMUser_Unmarshal_ReadsAllAttributes(t *testing.T) {
+	var u scim.Principal
+	if err := json.Unmarshal([]byte(fullSCIMUserJson), &u); err != nil {
+		t.Fatal(err)
+	}
+
+	if u.IsActive() {
+		t.Error("Expected inactive principal but got active principal")
+	}
+	if u.PreferredLanguage != "de_DE" || u.Locale != "de_DE" || u.Timezone != "Europe/Berlin" {
+		t.Errorf("Wrong localization attributes: got '%v', '%v', '%v'", u.PreferredLanguage, u.Locale, u.Timezone)
+	}
+	wantMeta := &scim.Meta{ResourceType: "User", Created: "2020-01-23T04:56:22Z", LastModified: "2021-05-13T04:42:34Z", Location: "/identityprovider/scim/users/146bc69e-1edf-40f6-bf68-849906998838"}
+	if !reflect.DeepEqual(u.Meta, wantMeta) {
+		t.Errorf("Wrong meta: got %v want %v", u.Meta, wantMeta)
+	}
+	wantPhone := scim.UserValue{Value: "+49 1235 9455-1234", Type: "work", Display: "work phone"}
+	if !reflect.DeepEqual(u.PhoneNumbers, []scim.UserValue{wantPhone}) {
+		t.Errorf("Wrong phoneNumbers: got %v want %v", u.PhoneNumbers, wantPhone)
+	}
+	wantEnterpriseUser := &scim.EnterpriseUser{EmployeeNumber: "313", Department: "Money Bin", Manager: &scim.Manager{Value: "26118915-6090-4610-87e4-49d8ca9f808d", DisplayName: "Scrooge McDuck"}}
+	if !reflect.DeepEqual(u.EnterpriseUser, wantEnterpriseUser) {
+		t.Errorf("Wrong enterprise user: got %v want %v", u.EnterpriseUser, wantEnterpriseUser)
+	}
+	if len(u.Extensions) != 1 {
+		t.Errorf("Expected exactly one unknown extension but got %v", u.Extensions)
+	}
+}
+
+func TestPrincipalWithUnknownExtension_Extension_DecodesExtension(t *testing.T) {
+	var u scim.Principal
+	if err := json.Unmarshal([]byte(fullSCIMUserJson), &u); err != nil {
+		t.Fatal(err)
+	}
+
+	var ext struct {
+		CostUnit string `json:"costUnit"`
+	}
+	found, err := u.Extension("urn:example:schemas:extension:dvelop:1.0:User", &ext)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || ext.CostUnit != "4711" {
+		t.Errorf("Wrong extension: got %v, %v want %v, %v", found, ext.CostUnit, true, "4711")
+	}
+}
+
+func TestPrincipalWithoutExtension_Extension_ReturnsFalse(t *testing.T) {
+	var ext struct{}
+	found, err := donaldDuck.Extension("urn:example:schemas:extension:dvelop:1.0:User", &ext)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Error("Expected false for principal without extension but got true")
+	}
+}
+
+func TestPrincipalWithUnknownExtension_RoundTrip_PreservesExtension(t *testing.T) {
+	var u scim.Principal
+	if err := json.Unmarshal([]byte(fullSCIMUserJson), &u); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want map[string]interface{}
+	_ = json.Unmarshal(b, &got)
+	_ = json.Unmarshal([]byte(fullSCIMUserJson), &want)
+
+	for k, v := range want {
+		if !reflect.DeepEqual(got[k], v) {
+			t.Errorf("Attribute '%v' not preserved: got %v want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestSetExtension_Marshal_WritesExtension(t *testing.T) {
+	p := scim.Principal{Id: "146bc69e-1edf-40f6-bf68-849906998838"}
+	if err := p.SetExtension("urn:example:schemas:extension:dvelop:1.0:User", map[string]string{"costUnit": "4711"}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	_ = json.Unmarshal(b, &got)
+
+	if !reflect.DeepEqual(got["urn:example:schemas:extension:dvelop:1.0:User"], map[string]interface{}{"costUnit": "4711"}) {
+		t.Errorf("Extension not serialized: got %s", b)
+	}
+}
+
+func TestExtensionWithNameOfKnownAttributeInOtherCase_Marshal_SkipsExtension(t *testing.T) {
+	p := scim.Principal{Id: "146bc69e-1edf-40f6-bf68-849906998838", Extensions: map[string]json.RawMessage{"ID": json.RawMessage(`"evil"`), "Active": json.RawMessage(`false`)}}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	_ = json.Unmarshal(b, &got)
+
+	for _, k := range []string{"ID", "Active", "active"} {
+		if _, found := got[k]; found {
+			t.Errorf("Extensions named like known attributes must not be serialized: got %s", b)
+		}
+	}
+	if got["id"] != "146bc69e-1edf-40f6-bf68-849906998838" {
+		t.Errorf("Extension must not replace known attribute: got %s", b)
+	}
+}
+
+func TestPrincipalHasNoActiveAttribute_IsActive_IsTrue(t *testing.T) {
+	p := scim.Principal{}
+
+	if !p.IsActive() {
+		t.Errorf("Expected true for principal without active attribute but got false")
+	}
+}
+
+func TestPrimaryEmail(t *testing.T) {
+	testcases := map[string]struct {
+		emails []scim.UserValue
+		want   string
+	}{
+		"NoEmails_ReturnsEmptyString":      {emails: nil, want: ""},
+		"NoPrimaryEmail_ReturnsFirstEmail": {emails: []scim.UserValue{{Value: "a@example.com"}, {Value: "b@example.com"}}, want: "a@example.com"},
+		"PrimaryEmail_ReturnsPrimaryEmail": {emails: []scim.UserValue{{Value: "a@example.com"}, {Value: "b@example.com", Primary: true}}, want: "b@example.com"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			p := scim.Principal{Emails: tc.emails}
+
+			if got := p.PrimaryEmail(); got != tc.want {
+				t.Errorf("Wrong primary email: got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
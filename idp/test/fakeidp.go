@@ -0,0 +1,600 @@
+package test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d-velop/dvelop-sdk-go/idp/scim"
+)
+
+const (
+	validatePath  = "/identityprovider/validate"
+	usersPath     = "/identityprovider/scim/users"
+	groupsPath    = "/identityprovider/scim/groups"
+	loginPath     = "/identityprovider/login"
+	logoutPath    = "/identityprovider/logout"
+	authSessionId = "AuthSessionId"
+
+	externalGroupId = "3E093BE5-CCCE-435D-99F8-544656B98681"
+
+	listResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	errorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// DefaultCacheControl is the Cache-Control header value which is sent by a FakeIdp for validate responses
+// if no other value has been configured.
+const DefaultCacheControl = "max-age=1800, private"
+
+// FakeIdp is a stateful fake of the IdentityProvider-App for integration tests.
+//
+// It serves the endpoints for validation, SCIM users and groups, login and logout on the URL of the
+// embedded httptest.Server. Users, groups and sessions can be changed while the server is running.
+// In addition faults like latency, unexpected status codes and malformed JSON can be injected.
+//
+// Example:
+//	func TestSomething(t *testing.T) {
+//		fake := test.NewFakeIdp()
+//		defer fake.Close()
+//		fake.AddUser(scim.Principal{Id: "9bbbf1b6-017a-449a-ad5f-9723d28223e1", UserName: "donald"})
+//		authSessionId, _ := fake.Login("9bbbf1b6-017a-449a-ad5f-9723d28223e1")
+//
+//		p, err := idpClient.Validate(ctx, fake.URL, "1", authSessionId)
+//		// ...
+//	}
+type FakeIdp struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	users          map[string]scim.Principal
+	groups         map[string]scim.Group
+	sessions       map[string]session
+	loginPrincipal string
+	cacheControl   string
+	latency        time.Duration
+	failWith       int
+	malformedJson  bool
+	requests       map[string]int
+}
+
+type session struct {
+	principalId       string
+	externalPrincipal *scim.Principal
+}
+
+// NewFakeIdp starts and returns a new FakeIdp without any users, groups and sessions.
+//
+// The caller should call Close when finished, to shut it down.
+func NewFakeIdp() *FakeIdp {
+	f := &FakeIdp{
+		users:        map[string]scim.Principal{},
+		groups:       map[string]scim.Group{},
+		sessions:     map[string]session{},
+		cacheControl: DefaultCacheControl,
+		requests:     map[string]int{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	return f
+}
+
+// AddUser adds a principal to the pool of known users or replaces the principal with the same id.
+func (f *FakeIdp) AddUser(p scim.Principal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.users[p.Id] = p
+}
+
+// RemoveUser removes the principal with the given id from the pool of known users.
+//
+// Existing sessions of the principal are no longer valid afterwards.
+func (f *FakeIdp) RemoveUser(principalId string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.users, principalId)
+}
+
+// AddGroup adds a group or replaces the group with the same id.
+func (f *FakeIdp) AddGroup(g scim.Group) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.groups[g.Id] = g
+}
+
+// AddSession adds a session with the given authSessionId for the known user specified by principalId.
+func (f *FakeIdp) AddSession(authSessionId string, principalId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.users[principalId]; !exists {
+		return fmt.Errorf("principal '%v' is unknown", principalId)
+	}
+	f.sessions[authSessionId] = session{principalId: principalId}
+	return nil
+}
+
+// AddExternalSession adds a session with the given authSessionId for an external user.
+//
+// External users are not part of the pool of known users. The principal is marked as an external user
+// if it isn't already a member of the reserved external group.
+func (f *FakeIdp) AddExternalSession(authSessionId string, p scim.Principal) {
+	if !p.IsExternal() {
+		p.Groups = append(p.Groups, scim.UserGroup{Value: externalGroupId})
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[authSessionId] = session{externalPrincipal: &p}
+}
+
+// Login creates a new session for the known user specified by principalId and returns the authSessionId.
+func (f *FakeIdp) Login(principalId string) (string, error) {
+	id, err := newAuthSessionId()
+	if err != nil {
+		return "", err
+	}
+	if err := f.AddSession(id, principalId); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// TerminateSession terminates the session specified by authSessionId.
+func (f *FakeIdp) TerminateSession(authSessionId string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.sessions, authSessionId)
+}
+
+// HasSession returns true, if the session specified by authSessionId exists.
+func (f *FakeIdp) HasSession(authSessionId string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, exists := f.sessions[authSessionId]
+	return exists
+}
+
+// SetLoginPrincipal sets the known user which is logged in by the login endpoint.
+//
+// If no login principal is set, the login endpoint answers with a login page instead of redirecting back.
+func (f *FakeIdp) SetLoginPrincipal(principalId string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loginPrincipal = principalId
+}
+
+// SetCacheControl sets the Cache-Control header value for validate responses.
+//
+// An empty value omits the header.
+func (f *FakeIdp) SetCacheControl(value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cacheControl = value
+}
+
+// SetLatency delays each response by the given duration.
+func (f *FakeIdp) SetLatency(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+}
+
+// FailWith answers each request with the given http status code. A status code of 0 disables the fault.
+func (f *FakeIdp) FailWith(statusCode int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failWith = statusCode
+}
+
+// SetMalformedJson answers each successful request with a malformed JSON body if enabled.
+func (f *FakeIdp) SetMalformedJson(enabled bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.malformedJson = enabled
+}
+
+// ResetFaults removes all injected faults.
+func (f *FakeIdp) ResetFaults() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = 0
+	f.failWith = 0
+	f.malformedJson = false
+}
+
+// RequestCount returns the number of requests which have been made for the given path.
+func (f *FakeIdp) RequestCount(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[path]
+}
+
+func (f *FakeIdp) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests[r.URL.Path]++
+	latency, failWith := f.latency, f.failWith
+	f.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if failWith != 0 {
+		http.Error(w, http.StatusText(failWith), failWith)
+		return
+	}
+
+	switch {
+	case r.URL.Path == validatePath:
+		f.validate(w, r)
+	case r.URL.Path == usersPath:
+		f.listUsers(w, r)
+	case strings.HasPrefix(r.URL.Path, usersPath+"/"):
+		f.getUser(w, r, strings.TrimPrefix(r.URL.Path, usersPath+"/"))
+	case r.URL.Path == groupsPath:
+		f.listGroups(w, r)
+	case strings.HasPrefix(r.URL.Path, groupsPath+"/"):
+		f.getGroup(w, r, strings.TrimPrefix(r.URL.Path, groupsPath+"/"))
+	case r.URL.Path == loginPath:
+		f.login(w, r)
+	case r.URL.Path == logoutPath:
+		f.logout(w, r)
+	default:
+		http.Error(w, "", http.StatusNotFound)
+	}
+}
+
+func (f *FakeIdp) validate(w http.ResponseWriter, r *http.Request) {
+	p, exists := f.principalFromRequest(r)
+	if !exists {
+		http.Error(w, "", http.StatusUnauthorized)
+		return
+	}
+	if p.IsExternal() && r.URL.Query().Get("allowExternalValidation") != "true" {
+		http.Error(w, "token is for external user", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	cacheControl := f.cacheControl
+	f.mu.Unlock()
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+	f.writeJson(w, p)
+}
+
+func (f *FakeIdp) getUser(w http.ResponseWriter, r *http.Request, principalId string) {
+	if !f.authorize(w, r) {
+		return
+	}
+	f.mu.Lock()
+	p, exists := f.users[principalId]
+	f.mu.Unlock()
+	if !exists {
+		writeScimError(w, http.StatusNotFound, "", fmt.Sprintf("user '%v' not found", principalId))
+		return
+	}
+	f.writeJson(w, p)
+}
+
+func (f *FakeIdp) listUsers(w http.ResponseWriter, r *http.Request) {
+	if !f.authorize(w, r) {
+		return
+	}
+	match, err := parseFilter(r.URL.Query().Get("filter"), userAttributeValues)
+	if err != nil {
+		writeScimError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	f.mu.Lock()
+	var resources []interface{}
+	for _, id := range sortedKeys(f.users) {
+		if p := f.users[id]; match(p) {
+			resources = append(resources, p)
+		}
+	}
+	f.mu.Unlock()
+	f.writeList(w, r, resources)
+}
+
+func (f *FakeIdp) getGroup(w http.ResponseWriter, r *http.Request, groupId string) {
+	if !f.authorize(w, r) {
+		return
+	}
+	f.mu.Lock()
+	g, exists := f.groups[groupId]
+	f.mu.Unlock()
+	if !exists {
+		writeScimError(w, http.StatusNotFound, "", fmt.Sprintf("group '%v' not found", groupId))
+		return
+	}
+	f.writeJson(w, g)
+}
+
+func (f *FakeIdp) listGroups(w http.ResponseWriter, r *http.Request) {
+	if !f.authorize(w, r) {
+		return
+	}
+	match, err := parseFilter(r.URL.Query().Get("filter"), groupAttributeValues)
+	if err != nil {
+		writeScimError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	f.mu.Lock()
+	var resources []interface{}
+	for _, id := range sortedKeys(f.groups) {
+		if g := f.groups[id]; match(g) {
+			resources = append(resources, g)
+		}
+	}
+	f.mu.Unlock()
+	f.writeList(w, r, resources)
+}
+
+func (f *FakeIdp) login(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	loginPrincipal := f.loginPrincipal
+	f.mu.Unlock()
+	if loginPrincipal == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprint(w, "<html><body>IdentityProvider login</body></html>")
+		return
+	}
+	id, err := f.Login(loginPrincipal)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: authSessionId, Value: url.QueryEscape(id), Path: "/", HttpOnly: true})
+	redirect := r.URL.Query().Get("redirect")
+	if redirect == "" {
+		redirect = "/"
+	}
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (f *FakeIdp) logout(w http.ResponseWriter, r *http.Request) {
+	if id, found := authSessionIdFromRequest(r); found {
+		f.TerminateSession(id)
+	}
+	http.SetCookie(w, &http.Cookie{Name: authSessionId, Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+	if redirect := r.URL.Query().Get("redirect"); redirect != "" {
+		http.Redirect(w, r, redirect, http.StatusFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize writes an error response and returns false if the request doesn't belong to a session of a known user
+func (f *FakeIdp) authorize(w http.ResponseWriter, r *http.Request) bool {
+	p, exists := f.principalFromRequest(r)
+	if !exists {
+		http.Error(w, `{"msg":"user unauthorized"}`, http.StatusUnauthorized)
+		return false
+	}
+	if p.IsExternal() {
+		http.Error(w, `{"msg":"user unauthorized"}`, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (f *FakeIdp) principalFromRequest(r *http.Request) (scim.Principal, bool) {
+	id, found := authSessionIdFromRequest(r)
+	if !found {
+		return scim.Principal{}, false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, exists := f.sessions[id]
+	if !exists {
+		return scim.Principal{}, false
+	}
+	if s.externalPrincipal != nil {
+		return *s.externalPrincipal, true
+	}
+	p, exists := f.users[s.principalId]
+	return p, exists
+}
+
+func authSessionIdFromRequest(r *http.Request) (string, bool) {
+	if matches := bearerTokenRegex.FindStringSubmatch(r.Header.Get("Authorization")); matches != nil {
+		return matches[1], true
+	}
+	if c, err := r.Cookie(authSessionId); err == nil {
+		if value, err := url.QueryUnescape(c.Value); err == nil {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (f *FakeIdp) writeJson(w http.ResponseWriter, v interface{}) {
+	f.mu.Lock()
+	malformedJson := f.malformedJson
+	f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/hal+json; charset=utf-8")
+	if malformedJson {
+		_, _ = fmt.Fprint(w, `{"wrong":"json}`)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *FakeIdp) writeList(w http.ResponseWriter, r *http.Request, resources []interface{}) {
+	total := len(resources)
+	startIndex, err := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	if startIndex > total {
+		resources = nil
+	} else {
+		resources = resources[startIndex-1:]
+	}
+	if count, err := strconv.Atoi(r.URL.Query().Get("count")); err == nil && count >= 0 && count < len(resources) {
+		resources = resources[:count]
+	}
+	if resources == nil {
+		resources = []interface{}{}
+	}
+	f.writeJson(w, struct {
+		Schemas      []string      `json:"schemas"`
+		TotalResults int           `json:"totalResults"`
+		ItemsPerPage int           `json:"itemsPerPage"`
+		StartIndex   int           `json:"startIndex"`
+		Resources    []interface{} `json:"Resources"`
+	}{[]string{listResponseSchema}, total, len(resources), startIndex, resources})
+}
+
+func writeScimError(w http.ResponseWriter, status int, scimType string, detail string) {
+	w.Header().Set("Content-Type", "application/scim+json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Schemas  []string `json:"schemas"`
+		ScimType string   `json:"scimType,omitempty"`
+		Detail   string   `json:"detail,omitempty"`
+		Status   string   `json:"status"`
+	}{[]string{errorSchema}, scimType, detail, strconv.Itoa(status)})
+}
+
+// filterRegex matches simple SCIM filter expressions like 'userName eq "donald"' cf. https://tools.ietf.org/html/rfc7644#section-3.4.2.2
+var filterRegex = regexp.MustCompile(`^\s*([A-Za-z][\w.]*)\s+(?i:(eq|ne|co|sw|ew))\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// parseFilter supports filter expressions consisting of exactly one attribute, operator and string value.
+// String comparison is case-insensitive as defined for the caseExact=false attributes of the core schema.
+func parseFilter(filter string, attributeValues func(resource interface{}, attribute string) []string) (func(resource interface{}) bool, error) {
+	if strings.TrimSpace(filter) == "" {
+		return func(interface{}) bool { return true }, nil
+	}
+	matches := filterRegex.FindStringSubmatch(filter)
+	if matches == nil {
+		return nil, fmt.Errorf("filter '%v' is not supported", filter)
+	}
+	attribute, operator := strings.ToLower(matches[1]), strings.ToLower(matches[2])
+	var expected string
+	if err := json.Unmarshal([]byte(`"`+matches[3]+`"`), &expected); err != nil {
+		return nil, fmt.Errorf("filter '%v' contains an invalid value", filter)
+	}
+	expected = strings.ToLower(expected)
+	compare := map[string]func(a, b string) bool{
+		"eq": func(a, b string) bool { return a == b },
+		"co": strings.Contains,
+		"sw": strings.HasPrefix,
+		"ew": strings.HasSuffix,
+	}
+	anyValueMatches := func(resource interface{}, matches func(a, b string) bool) bool {
+		for _, v := range attributeValues(resource, attribute) {
+			if matches(strings.ToLower(v), expected) {
+				return true
+			}
+		}
+		return false
+	}
+	if operator == "ne" {
+		return func(resource interface{}) bool {
+			return attributeValues(resource, attribute) != nil && !anyValueMatches(resource, compare["eq"])
+		}, nil
+	}
+	return func(resource interface{}) bool {
+		return anyValueMatches(resource, compare[operator])
+	}, nil
+}
+
+func userAttributeValues(resource interface{}, attribute string) []string {
+	p := resource.(scim.Principal)
+	switch attribute {
+	case "id":
+		return []string{p.Id}
+	case "externalid":
+		return []string{p.ExternalId}
+	case "username":
+		return []string{p.UserName}
+	case "displayname":
+		return []string{p.DisplayName}
+	case "title":
+		return []string{p.Title}
+	case "name.familyname":
+		return []string{p.Name.FamilyName}
+	case "name.givenname":
+		return []string{p.Name.GivenName}
+	case "emails", "emails.value":
+		return userValues(p.Emails)
+	case "phonenumbers", "phonenumbers.value":
+		return userValues(p.PhoneNumbers)
+	case "groups", "groups.value":
+		var values []string
+		for _, g := range p.Groups {
+			values = append(values, g.Value)
+		}
+		return values
+	case "groups.display":
+		var values []string
+		for _, g := range p.Groups {
+			values = append(values, g.Display)
+		}
+		return values
+	}
+	return nil
+}
+
+func userValues(values []scim.UserValue) []string {
+	var result []string
+	for _, v := range values {
+		result = append(result, v.Value)
+	}
+	return result
+}
+
+func groupAttributeValues(resource interface{}, attribute string) []string {
+	g := resource.(scim.Group)
+	switch attribute {
+	case "id":
+		return []string{g.Id}
+	case "externalid":
+		return []string{g.ExternalId}
+	case "displayname":
+		return []string{g.DisplayName}
+	case "members", "members.value":
+		var values []string
+		for _, m := range g.Members {
+			values = append(values, m.Value)
+		}
+		return values
+	}
+	return nil
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch v := m.(type) {
+	case map[string]scim.Principal:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]scim.Group:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newAuthSessionId() (string, error) {
+	b := make([]byte, 48)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
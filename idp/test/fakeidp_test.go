@@ -0,0 +1,338 @@
+package test_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/d-velop/dvelop-sdk-go/idp/idpclient"
+	"github.com/d-velop/dvelop-sdk-go/idp/scim"
+	"github.com/d-velop/dvelop-sdk-go/idp/test"
+)
+
+var donald = scim.Principal{Id: "9bbbf1b6-017a-449a-ad5f-9723d28223e1", UserName: "donald", DisplayName: "Donald Duck", Emails: []scim.UserValue{{Value: "donald@entenhausen.de"}}}
+var daisy = scim.Principal{Id: "1234f1b6-017a-449a-ad5f-9723d2822fff", UserName: "daisy", DisplayName: "Daisy Duck", Emails: []scim.UserValue{{Value: "daisy@entenhausen.de"}}}
+var ducks = scim.Group{Id: "d84b34da-c60e-495e-9a0d-59507630be3a", DisplayName: "Ducks", Members: []scim.GroupMember{{Value: donald.Id}, {Value: daisy.Id}}}
+
+func newFakeIdp() *test.FakeIdp {
+	fake := test.NewFakeIdp()
+	fake.AddUser(donald)
+	fake.AddUser(daisy)
+	fake.AddGroup(ducks)
+	return fake
+}
+
+func newClient(t *testing.T) idpClient {
+	c, err := idpclient.New(idpclient.PrincipalCache(noCache{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+type idpClient interface {
+	Validate(ctx context.Context, systemBaseUri string, tenantId string, authSessionId string) (*scim.Principal, error)
+	GetPrincipalById(ctx context.Context, systemBaseUri string, tenantId string, authSessionId string, principalId string) (*scim.Principal, error)
+}
+
+type noCache struct{}
+
+func (noCache) Get(string) (interface{}, bool)         { return nil, false }
+func (noCache) Set(string, interface{}, time.Duration) {}
+
+func TestLoggedInUser_Validate_ReturnsPrincipal(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, err := fake.Login(donald.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := newClient(t).Validate(context.Background(), fake.URL, "1", authSessionId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || !reflect.DeepEqual(*p, donald) {
+		t.Errorf("wrong principal: got %v want %v", p, donald)
+	}
+}
+
+func TestTerminatedSession_Validate_ReturnsNilPrincipal(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+	fake.TerminateSession(authSessionId)
+
+	p, err := newClient(t).Validate(context.Background(), fake.URL, "1", authSessionId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != nil {
+		t.Errorf("expected nil principal but got %v", p)
+	}
+}
+
+func TestExternalSession_Validate_ReturnsExternalPrincipal(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	fake.AddExternalSession("external", scim.Principal{Emails: []scim.UserValue{{Value: "info@d-velop.de"}}})
+
+	p, err := newClient(t).Validate(context.Background(), fake.URL, "1", "external")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || !p.IsExternal() {
+		t.Errorf("expected external principal but got %v", p)
+	}
+}
+
+func TestUserChangedAfterLogin_GetPrincipalById_ReturnsChangedPrincipal(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+	changed := daisy
+	changed.Title = "Boss"
+	fake.AddUser(changed)
+
+	p, err := newClient(t).GetPrincipalById(context.Background(), fake.URL, "1", authSessionId, daisy.Id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || !reflect.DeepEqual(*p, changed) {
+		t.Errorf("wrong principal: got %v want %v", p, changed)
+	}
+}
+
+func TestCacheControl_Validate_SendsConfiguredHeader(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+	fake.SetCacheControl("max-age=1, private")
+
+	resp := get(t, fake.URL+"/identityprovider/validate", authSessionId)
+
+	if got := resp.Header.Get("Cache-Control"); got != "max-age=1, private" {
+		t.Errorf("wrong Cache-Control header: got %v want %v", got, "max-age=1, private")
+	}
+}
+
+func TestFilter_ListUsers_ReturnsMatchingUsers(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+	testcases := map[string]struct {
+		filter string
+		want   []string
+	}{
+		"NoFilter":          {filter: "", want: []string{daisy.Id, donald.Id}},
+		"UserNameEq":        {filter: `userName eq "donald"`, want: []string{donald.Id}},
+		"UserNameEqIgnCase": {filter: `username EQ "DONALD"`, want: []string{donald.Id}},
+		"EmailsStartsWith":  {filter: `emails.value sw "daisy@"`, want: []string{daisy.Id}},
+		"DisplayNameCo":     {filter: `displayName co "Duck"`, want: []string{daisy.Id, donald.Id}},
+		"NoMatch":           {filter: `userName eq "gustav"`, want: nil},
+		"UserNameNe":        {filter: `userName ne "donald"`, want: []string{daisy.Id}},
+		"UserNameNeNoMatch": {filter: `userName ne "gustav"`, want: []string{daisy.Id, donald.Id}},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			resp := get(t, fake.URL+"/identityprovider/scim/users?filter="+url.QueryEscape(tc.filter), authSessionId)
+
+			var list struct {
+				TotalResults int              `json:"totalResults"`
+				Resources    []scim.Principal `json:"Resources"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range list.Resources {
+				got = append(got, p.Id)
+			}
+			if !reflect.DeepEqual(got, tc.want) || list.TotalResults != len(tc.want) {
+				t.Errorf("wrong users: got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnsupportedFilter_ListUsers_ReturnsStatus400(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+
+	resp := get(t, fake.URL+"/identityprovider/scim/users?filter="+url.QueryEscape(`userName pr`), authSessionId)
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("wrong status code: got %v want %v", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestFilter_ListGroups_ReturnsMatchingGroups(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+
+	resp := get(t, fake.URL+"/identityprovider/scim/groups?filter="+url.QueryEscape(`members eq "`+daisy.Id+`"`), authSessionId)
+
+	var list struct {
+		Resources []scim.Group `json:"Resources"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(list.Resources, []scim.Group{ducks}) {
+		t.Errorf("wrong groups: got %v want %v", list.Resources, []scim.Group{ducks})
+	}
+}
+
+func TestNoSession_ListUsers_ReturnsStatus401(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+
+	resp := get(t, fake.URL+"/identityprovider/scim/users", "unknown")
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong status code: got %v want %v", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestLoginPrincipal_Login_SetsCookieAndRedirects(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	fake.SetLoginPrincipal(donald.Id)
+
+	resp := get(t, fake.URL+"/identityprovider/login?redirect="+url.QueryEscape("/app/resource"), "")
+
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/app/resource" {
+		t.Errorf("wrong redirect: got %v %v", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	var authSessionId string
+	for _, c := range resp.Cookies() {
+		if c.Name == "AuthSessionId" {
+			authSessionId, _ = url.QueryUnescape(c.Value)
+		}
+	}
+	if !fake.HasSession(authSessionId) {
+		t.Errorf("login didn't create a session")
+	}
+}
+
+func TestClientWithCookieJar_Login_SendsSessionCookieOnFollowingRequests(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	fake.SetLoginPrincipal(donald.Id)
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar}
+
+	resp, err := client.Get(fake.URL + "/identityprovider/login?redirect=" + url.QueryEscape("/identityprovider/validate"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("wrong status code: got %v want %v", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestSession_Logout_TerminatesSessionAndRedirects(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+
+	resp := get(t, fake.URL+"/identityprovider/logout?redirect="+url.QueryEscape("/app"), authSessionId)
+
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/app" {
+		t.Errorf("wrong redirect: got %v %v", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if fake.HasSession(authSessionId) {
+		t.Errorf("logout didn't terminate the session")
+	}
+}
+
+func TestFailWith_Validate_ReturnsError(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+	fake.FailWith(http.StatusServiceUnavailable)
+
+	_, err := newClient(t).Validate(context.Background(), fake.URL, "1", authSessionId)
+
+	if err == nil {
+		t.Error("expected error but got nil")
+	}
+	fake.ResetFaults()
+	if _, err := newClient(t).Validate(context.Background(), fake.URL, "1", authSessionId); err != nil {
+		t.Errorf("expected no error after reset but got %v", err)
+	}
+}
+
+func TestMalformedJson_Validate_ReturnsError(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+	fake.SetMalformedJson(true)
+
+	_, err := newClient(t).Validate(context.Background(), fake.URL, "1", authSessionId)
+
+	if err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func TestLatency_ValidateWithTimeout_ReturnsTimeout(t *testing.T) {
+	fake := newFakeIdp()
+	defer fake.Close()
+	authSessionId, _ := fake.Login(donald.Id)
+	fake.SetLatency(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	_, err := newClient(t).Validate(ctx, fake.URL, "1", authSessionId)
+
+	var urlError *url.Error
+	if !errors.As(err, &urlError) || !urlError.Timeout() {
+		t.Errorf("expected timeout but got %v", err)
+	}
+	if fake.RequestCount("/identityprovider/validate") != 1 {
+		t.Errorf("wrong request count: got %v want %v", fake.RequestCount("/identityprovider/validate"), 1)
+	}
+}
+
+func get(t *testing.T, u string, authSessionId string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authSessionId != "" {
+		req.Header.Set("Authorization", "Bearer "+authSessionId)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp
+}
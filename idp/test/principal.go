@@ -0,0 +1,70 @@
+package test
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/d-velop/dvelop-sdk-go/idp"
+	"github.com/d-velop/dvelop-sdk-go/idp/scim"
+)
+
+const adminGroupId = "3E093BE5-CCCE-435D-99F8-544656B98680"
+
+// ContextWithPrincipal returns a new context.Context with the given principal and authSessionId as if the
+// request has been authenticated by idp.Authenticate.
+//
+// Use it to test handlers which read the principal by idp.PrincipalFromCtx without an IdentityProvider.
+func ContextWithPrincipal(ctx context.Context, principal scim.Principal, authSessionId string) context.Context {
+	ctx = idp.SetAuthSessionId(ctx, authSessionId)
+	return idp.SetPrincipal(ctx, principal)
+}
+
+// RequestWithPrincipal returns a shallow copy of req whose context contains the given principal and authSessionId
+// as if the request has been authenticated by idp.Authenticate.
+//
+// Example:
+//	func TestHelloHandler(t *testing.T) {
+//		req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+//		req = test.RequestWithPrincipal(req, test.InternalUser(), "anyAuthSessionId")
+//
+//		helloHandler().ServeHTTP(httptest.NewRecorder(), req)
+//		// ...
+//	}
+func RequestWithPrincipal(req *http.Request, principal scim.Principal, authSessionId string) *http.Request {
+	return req.WithContext(ContextWithPrincipal(req.Context(), principal, authSessionId))
+}
+
+// InternalUser returns a principal representing a typical user which is known to the tenant.
+func InternalUser() scim.Principal {
+	return scim.Principal{
+		Id:          "9bbbf1b6-017a-449a-ad5f-9723d28223e1",
+		UserName:    "donald",
+		Name:        scim.UserName{FamilyName: "Duck", GivenName: "Donald"},
+		DisplayName: "Donald Duck",
+		Emails:      []scim.UserValue{{Value: "donald.duck@entenhausen.de", Primary: true}},
+		Groups:      []scim.UserGroup{{Value: "d84b34da-c60e-495e-9a0d-59507630be3a", Display: "Developer"}},
+	}
+}
+
+// ExternalUser returns a principal representing an external user.
+//
+// Like the principals returned by the IdentityProvider-App for external users it contains nothing
+// apart from the e-mail address and the reserved group which marks the user as external.
+func ExternalUser() scim.Principal {
+	return scim.Principal{
+		Emails: []scim.UserValue{{Value: "info@d-velop.de"}},
+		Groups: []scim.UserGroup{{Value: externalGroupId}},
+	}
+}
+
+// AdminUser returns a principal representing a user which is a member of the tenant's administrators group.
+func AdminUser() scim.Principal {
+	return scim.Principal{
+		Id:          "1234f1b6-017a-449a-ad5f-9723d2822fff",
+		UserName:    "scrooge",
+		Name:        scim.UserName{FamilyName: "McDuck", GivenName: "Scrooge"},
+		DisplayName: "Scrooge McDuck",
+		Emails:      []scim.UserValue{{Value: "scrooge.mcduck@entenhausen.de", Primary: true}},
+		Groups:      []scim.UserGroup{{Value: adminGroupId, Display: "Administrators"}},
+	}
+}
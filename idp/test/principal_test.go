@@ -0,0 +1,54 @@
+package test_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/idp"
+	"github.com/d-velop/dvelop-sdk-go/idp/test"
+)
+
+func TestRequestWithPrincipal_PrincipalFromCtx_ReturnsPrincipal(t *testing.T) {
+	req := test.RequestWithPrincipal(httptest.NewRequest(http.MethodGet, "/hello", nil), test.InternalUser(), "authSessionId")
+
+	p, err := idp.PrincipalFromCtx(req.Context())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p, test.InternalUser()) {
+		t.Errorf("wrong principal on context: got %v want %v", p, test.InternalUser())
+	}
+}
+
+func TestContextWithPrincipal_AuthSessionIdFromCtx_ReturnsAuthSessionId(t *testing.T) {
+	ctx := test.ContextWithPrincipal(context.Background(), test.AdminUser(), "authSessionId")
+
+	authSessionId, err := idp.AuthSessionIdFromCtx(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authSessionId != "authSessionId" {
+		t.Errorf("wrong authSessionId on context: got %v want %v", authSessionId, "authSessionId")
+	}
+}
+
+func TestExternalUser_IsExternal_IsTrue(t *testing.T) {
+	p := test.ExternalUser()
+
+	if !p.IsExternal() {
+		t.Error("expected external principal but got internal principal")
+	}
+}
+
+func TestInternalUser_IsExternal_IsFalse(t *testing.T) {
+	p := test.InternalUser()
+
+	if p.IsExternal() {
+		t.Error("expected internal principal but got external principal")
+	}
+}
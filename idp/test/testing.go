@@ -1,3 +1,4 @@
+// Package test provides stubs and fakes of the IdentityProvider-App for tests.
 package test
 
 import (
@@ -122,13 +122,14 @@ func TestJsonStringWithDurationInMilliseconds_Unmarshal_ProducesCorrectDuration(
 func TestEventWithAllProperties_Marshal_JsonObjectWithAllProperties(t *testing.T) {
 	ti := time.Date(2021, 06, 04, 07, 22, 48, 0, time.UTC)
 	e := log.Event{
-		Time:     &ti,
-		Severity: log.SeverityInfo,
-		Name:     "VacationRequested",
-		Body:     "A normal message",
-		TenantId: "45f",
-		TraceId:  "f4dbb3edd765f620",
-		SpanId:   "14dbb3edd765f650",
+		Time:         &ti,
+		Severity:     log.SeverityInfo,
+		Name:         "VacationRequested",
+		Body:         "A normal message",
+		TenantId:     "45f",
+		TraceId:      "f4dbb3edd765f620",
+		SpanId:       "14dbb3edd765f650",
+		ParentSpanId: "00f067aa0ba902b7",
 		Resource: &log.Resource{
 			Service: &log.Service{
 				Name:     "vacationprocessapp",
@@ -179,6 +180,7 @@ func TestEventWithAllProperties_Marshal_JsonObjectWithAllProperties(t *testing.T
 		"tn":"45f",
 		"trace":"f4dbb3edd765f620",
 		"span": "14dbb3edd765f650",
+		"pspan": "00f067aa0ba902b7",
 		"res": {   
 	        "svc":{
     	        "name": "vacationprocessapp",
@@ -0,0 +1,97 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+// A GeneratorFunc generates a new request id for a request without a valid request id.
+type GeneratorFunc func(ctx context.Context) (string, error)
+
+// Generator sets the function which generates new request ids. Default is NewUUIDv4.
+//
+// If the generator fails a random UUID is used instead.
+//
+// Example:
+//	// use the trace-id of the tracecontext package and time ordered ids for requests without trace-id
+//	requestid.AddToCtx(requestid.Generator(requestid.FromTraceId(tracecontext.TraceIdFromCtx, requestid.NewUUIDv7)))
+func Generator(g GeneratorFunc) Option {
+	return func(c *config) {
+		c.generate = g
+	}
+}
+
+// NewUUIDv4 generates a random UUID like 550e8400-e29b-41d4-a716-446655440000.
+func NewUUIDv4(context.Context) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// NewUUIDv7 generates a time ordered UUID version 7 like 017f22e2-79b0-7cc3-98c4-dc0c0c07398f,
+// which consists of the current unix timestamp in milliseconds followed by random bits.
+// Ids created in different milliseconds sort chronologically.
+func NewUUIDv7(context.Context) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	putTimestamp(b[:6], time.Now())
+	b[6] = (b[6] & 0x0f) | 0x70 // version 7
+	b[8] = (b[8] & 0x3f) | 0x80 // variant RFC 4122
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32], nil
+}
+
+// crockfordBase32 is the alphabet of ULIDs cf. https://github.com/ulid/spec
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID generates a ULID like 01ARZ3NDEKTSV4RRFFQ69G5FAV (cf. https://github.com/ulid/spec), which consists of the
+// current unix timestamp in milliseconds followed by random bits. Ids created in different milliseconds sort chronologically.
+func NewULID(context.Context) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	putTimestamp(b[:6], time.Now())
+	// 128 bits are encoded as 26 characters with 5 bits each. The first character holds the 3 most significant bits.
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:]), nil
+}
+
+// FromTraceId returns a GeneratorFunc which uses the trace-id from the context as request id, so that request ids
+// line up with traces. If there is no trace-id on the context the id is generated by fallback.
+//
+// The trace-id is read by getTraceIdFromCtx, e.g. tracecontext.TraceIdFromCtx, so the tracecontext middleware
+// must be applied before the requestid middleware.
+func FromTraceId(getTraceIdFromCtx func(ctx context.Context) (string, error), fallback GeneratorFunc) GeneratorFunc {
+	return func(ctx context.Context) (string, error) {
+		if traceId, err := getTraceIdFromCtx(ctx); err == nil && traceId != "" {
+			return traceId, nil
+		}
+		return fallback(ctx)
+	}
+}
+
+// putTimestamp writes the unix timestamp in milliseconds as 48 bit big endian integer into b
+func putTimestamp(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
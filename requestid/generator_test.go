@@ -0,0 +1,128 @@
+package requestid_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/d-velop/dvelop-sdk-go/requestid"
+)
+
+var (
+	uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidPattern   = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+func TestGenerators_ReturnIdsInTheirFormat(t *testing.T) {
+	testcases := map[string]struct {
+		generate requestid.GeneratorFunc
+		pattern  *regexp.Regexp
+	}{
+		"UUIDv4": {requestid.NewUUIDv4, uuidV4Pattern},
+		"UUIDv7": {requestid.NewUUIDv7, uuidV7Pattern},
+		"ULID":   {requestid.NewULID, ulidPattern},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			id, err := tc.generate(context.Background())
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.pattern.MatchString(id) {
+				t.Errorf("wrong format: got %v", id)
+			}
+			if !requestid.DefaultValidator(id) {
+				t.Errorf("id is not accepted by DefaultValidator: %v", id)
+			}
+		})
+	}
+}
+
+func TestTimeOrderedGenerators_IdsSortChronologically(t *testing.T) {
+	for name, generate := range map[string]requestid.GeneratorFunc{"UUIDv7": requestid.NewUUIDv7, "ULID": requestid.NewULID} {
+		t.Run(name, func(t *testing.T) {
+			first, _ := generate(context.Background())
+			time.Sleep(2 * time.Millisecond)
+			second, _ := generate(context.Background())
+
+			if !(first < second) {
+				t.Errorf("ids don't sort chronologically: %v is not less than %v", first, second)
+			}
+		})
+	}
+}
+
+func TestULID_StartsWithTimestamp(t *testing.T) {
+	before := time.Now().UnixNano() / int64(time.Millisecond)
+	id, _ := requestid.NewULID(context.Background())
+	after := time.Now().UnixNano() / int64(time.Millisecond)
+
+	// the first character contains 3 bits and the following 9 characters 5 bits each, which yields the 48 bits of the timestamp
+	var ms int64
+	for _, c := range id[:10] {
+		ms = ms<<5 | int64(strings.IndexRune("0123456789ABCDEFGHJKMNPQRSTVWXYZ", c))
+	}
+	if ms < before || ms > after {
+		t.Errorf("wrong timestamp in %v: got %v want between %v and %v", id, ms, before, after)
+	}
+}
+
+func TestTraceIdOnCtx_FromTraceId_ReturnsTraceId(t *testing.T) {
+	generate := requestid.FromTraceId(traceIdFromCtx("4bf92f3577b34da6a3ce929d0e0e4736", nil), failingGenerator)
+
+	id, err := generate(context.Background())
+
+	if err != nil || id != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("wrong id: got %v, %v", id, err)
+	}
+}
+
+func TestNoTraceIdOnCtx_FromTraceId_UsesFallback(t *testing.T) {
+	generate := requestid.FromTraceId(traceIdFromCtx("", errors.New("no traceId on context")), requestid.NewULID)
+
+	id, err := generate(context.Background())
+
+	if err != nil || !ulidPattern.MatchString(id) {
+		t.Errorf("wrong id: got %v, %v", id, err)
+	}
+}
+
+func TestGenerator_UsesGeneratorForNewIds(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+	innerHandler := handlerSpy{}
+
+	requestid.AddToCtx(requestid.Generator(requestid.NewUUIDv7))(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if !uuidV7Pattern.MatchString(innerHandler.reqid) {
+		t.Errorf("wrong requestid: got %v", innerHandler.reqid)
+	}
+}
+
+func TestGeneratorFails_UsesRandomUUID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+	innerHandler := handlerSpy{}
+
+	requestid.AddToCtx(requestid.Generator(failingGenerator))(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if !uuidV4Pattern.MatchString(innerHandler.reqid) {
+		t.Errorf("wrong requestid: got %v", innerHandler.reqid)
+	}
+}
+
+func failingGenerator(context.Context) (string, error) {
+	return "", errors.New("generator failed")
+}
+
+func traceIdFromCtx(traceId string, err error) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		return traceId, err
+	}
+}
@@ -0,0 +1,56 @@
+package requestid
+
+import (
+	"regexp"
+)
+
+// MaxLength is the maximum length of a request id which is accepted by DefaultValidator.
+const MaxLength = 128
+
+var validRequestId = regexp.MustCompile(`^[a-zA-Z0-9._:+/=-]+$`)
+
+// An Option configures the behaviour of AddToCtx.
+type Option func(*config)
+
+type config struct {
+	isValid        func(reqId string) bool
+	responseHeader bool
+	generate       GeneratorFunc
+}
+
+func newConfig(options []Option) *config {
+	c := &config{
+		isValid:        DefaultValidator,
+		responseHeader: true,
+		generate:       NewUUIDv4,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+// DefaultValidator accepts request ids with at most MaxLength characters which consist of letters, digits
+// and the characters '.', '_', ':', '+', '/', '=' and '-'. This covers UUIDs, ULIDs and base64 encoded ids
+// but prevents whitespace and control characters from ending up in the logs.
+func DefaultValidator(reqId string) bool {
+	return len(reqId) <= MaxLength && validRequestId.MatchString(reqId)
+}
+
+// Validator sets the function which decides whether the request id of a request is accepted.
+// Invalid ids are replaced by a new one. Default is DefaultValidator.
+//
+// Use a function which always returns true to accept all request ids.
+func Validator(isValid func(reqId string) bool) Option {
+	return func(c *config) {
+		c.isValid = isValid
+	}
+}
+
+// ResponseHeader sets whether the request id is returned in the http header x-dv-request-id of the response,
+// so that a client can refer to it e.g. in support tickets. Default is true.
+func ResponseHeader(enabled bool) Option {
+	return func(c *config) {
+		c.responseHeader = enabled
+	}
+}
@@ -2,6 +2,7 @@
 //
 // The idea is to read the current id from the received request and pass it
 // to downstream services in order to get a trace across service boundaries.
+// Use Transport to pass the id on with outbound requests.
 //
 // Logstatements should log the request id in every statement to correlate
 // the statements to a specific request. This simplifies the tracking of
@@ -37,23 +38,35 @@ const reqIdHeader = "x-dv-request-id"
 // AddToCtx reads the requestid http header x-dv-request-id from the current request
 // and stores the id in the context.
 //
-// If the request doesn't have an existing id a new one is generated.
-func AddToCtx() func(http.Handler) http.Handler {
+// If the request doesn't have an existing id or the id is invalid (cf. Validator) a new one is generated (cf. Generator).
+// The id is returned in the http header x-dv-request-id of the response unless disabled by ResponseHeader.
+func AddToCtx(options ...Option) func(http.Handler) http.Handler {
+	c := newConfig(options)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 			ctx := req.Context()
 
 			reqId := req.Header.Get(reqIdHeader)
-			if reqId == "" {
-				reqId = uuid.Must(uuid.NewV4()).String()
+			if reqId == "" || !c.isValid(reqId) {
+				reqId = c.newRequestId(ctx)
 			}
 			ctx = context.WithValue(ctx, reqIdCtxKey, reqId)
+			if c.responseHeader {
+				rw.Header().Set(reqIdHeader, reqId)
+			}
 
 			next.ServeHTTP(rw, req.WithContext(ctx))
 		})
 	}
 }
 
+func (c *config) newRequestId(ctx context.Context) string {
+	if reqId, err := c.generate(ctx); err == nil && reqId != "" {
+		return reqId
+	}
+	return uuid.Must(uuid.NewV4()).String()
+}
+
 // FromCtx reads the current request id from the context.
 func FromCtx(ctx context.Context) (string, error) {
 	reqId, ok := ctx.Value(reqIdCtxKey).(string)
@@ -62,3 +75,8 @@ func FromCtx(ctx context.Context) (string, error) {
 	}
 	return reqId, nil
 }
+
+// Set returns a new context.Context with the given request id
+func Set(ctx context.Context, reqId string) context.Context {
+	return context.WithValue(ctx, reqIdCtxKey, reqId)
+}
@@ -1,9 +1,11 @@
 package requestid_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/d-velop/dvelop-sdk-go/requestid"
@@ -53,6 +55,91 @@ func TestRequestIdHeader_UsesHeader(t *testing.T) {
 	}
 }
 
+func TestInvalidRequestIdHeader_GeneratesNewId(t *testing.T) {
+	testcases := map[string]string{
+		"Newline":    "abc\ninjected log line",
+		"Whitespace": "abc def",
+		"Control":    "abc\x00",
+		"TooLong":    strings.Repeat("a", 129),
+		"NonAscii":   "äöü",
+	}
+
+	for name, reqId := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+			req.Header.Set("x-dv-request-id", reqId)
+			innerHandler := handlerSpy{}
+
+			requestid.AddToCtx()(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+			if err := innerHandler.assertRequestIdIsSet(); err != nil {
+				t.Error(err)
+			}
+			if innerHandler.reqid == reqId {
+				t.Errorf("invalid requestid should have been replaced: got %q", innerHandler.reqid)
+			}
+		})
+	}
+}
+
+func TestValidRequestIdHeader_UsesHeader(t *testing.T) {
+	for _, reqId := range []string{"550e8400-e29b-11d4-a716-446655440000", "01ARZ3NDEKTSV4RRFFQ69G5FAV", "Root=1-5759e988-bd862e3fe1be46a994272793", "YWJj+/==", strings.Repeat("a", 128)} {
+		t.Run(reqId, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+			req.Header.Set("x-dv-request-id", reqId)
+			innerHandler := handlerSpy{}
+
+			requestid.AddToCtx()(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+			if err := innerHandler.assertRequestIdIs(reqId); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCustomValidator_UsesValidator(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+	req.Header.Set("x-dv-request-id", "abc def")
+	innerHandler := handlerSpy{}
+
+	requestid.AddToCtx(requestid.Validator(func(string) bool { return true }))(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := innerHandler.assertRequestIdIs("abc def"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRequestId_SetsResponseHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+	innerHandler := handlerSpy{}
+	rec := httptest.NewRecorder()
+
+	requestid.AddToCtx()(&innerHandler).ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("x-dv-request-id"); got == "" || got != innerHandler.reqid {
+		t.Errorf("wrong response header: got %v want %v", got, innerHandler.reqid)
+	}
+}
+
+func TestResponseHeaderDisabled_DoesNotSetResponseHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+	rec := httptest.NewRecorder()
+
+	requestid.AddToCtx(requestid.ResponseHeader(false))(&handlerSpy{}).ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("x-dv-request-id"); got != "" {
+		t.Errorf("response header should not be set: got %v", got)
+	}
+}
+
+func TestRequestIdOnContext_Set_ReturnsContextWithRequestId(t *testing.T) {
+	ctx := requestid.Set(context.Background(), "1234")
+	if id, _ := requestid.FromCtx(ctx); id != "1234" {
+		t.Errorf("got wrong requestid from context: got %v want %v", id, "1234")
+	}
+}
+
 type handlerSpy struct {
 	hasBeenCalled bool
 	reqid         string
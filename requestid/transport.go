@@ -0,0 +1,59 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+)
+
+type transport struct {
+	fallbacks []func(ctx context.Context) (string, error)
+	next      http.RoundTripper
+}
+
+// Transport returns a http.RoundTripper which passes the request id from the request context on to downstream services
+// in the http header x-dv-request-id.
+//
+// The id is read by FromCtx. If there is none the fallbacks are asked in the given order, e.g. lambda.ReqIdFromCtx
+// to use the request id of the Lambda invocation for outbound requests which aren't caused by a http request.
+// Requests which already contain the header or for which no id is found are passed on unchanged.
+// If next is nil http.DefaultTransport is used.
+//
+// Example:
+//	client := &http.Client{
+//		Transport: requestid.Transport(nil, lambda.ReqIdFromCtx),
+//	}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://otherservice.example.com/resource", nil)
+//	resp, err := client.Do(req)
+func Transport(next http.RoundTripper, fallbacks ...func(ctx context.Context) (string, error)) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{fallbacks: fallbacks, next: next}
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(reqIdHeader) != "" {
+		return t.next.RoundTrip(req)
+	}
+	reqId := t.reqIdFromCtx(req.Context())
+	if reqId == "" {
+		return t.next.RoundTrip(req)
+	}
+	// a RoundTripper must not modify the original request cf. https://golang.org/pkg/net/http/#RoundTripper
+	outReq := req.Clone(req.Context())
+	outReq.Header.Set(reqIdHeader, reqId)
+	return t.next.RoundTrip(outReq)
+}
+
+func (t *transport) reqIdFromCtx(ctx context.Context) string {
+	if reqId, err := FromCtx(ctx); err == nil && reqId != "" {
+		return reqId
+	}
+	for _, fromCtx := range t.fallbacks {
+		if reqId, err := fromCtx(ctx); err == nil && reqId != "" {
+			return reqId
+		}
+	}
+	return ""
+}
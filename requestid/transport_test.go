@@ -0,0 +1,99 @@
+package requestid_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/requestid"
+)
+
+func TestRequestIdOnContext_Transport_SetsHeader(t *testing.T) {
+	spy := &roundTripperSpy{}
+	req := httptest.NewRequest(http.MethodGet, "https://otherservice.example.com/resource", nil).WithContext(requestid.Set(context.Background(), "1234"))
+
+	requestid.Transport(spy, lambdaReqId("lambda-5678")).RoundTrip(req)
+
+	if got := spy.req.Header.Get("x-dv-request-id"); got != "1234" {
+		t.Errorf("wrong header: got %v want %v", got, "1234")
+	}
+	if req.Header.Get("x-dv-request-id") != "" {
+		t.Error("original request must not be modified")
+	}
+}
+
+func TestOnlyLambdaRequestIdOnContext_Transport_SetsHeaderFromFallback(t *testing.T) {
+	spy := &roundTripperSpy{}
+	req := httptest.NewRequest(http.MethodGet, "https://otherservice.example.com/resource", nil)
+
+	requestid.Transport(spy, noReqId, lambdaReqId("lambda-5678")).RoundTrip(req)
+
+	if got := spy.req.Header.Get("x-dv-request-id"); got != "lambda-5678" {
+		t.Errorf("wrong header: got %v want %v", got, "lambda-5678")
+	}
+}
+
+func TestNoRequestIdOnContext_Transport_DoesntSetHeader(t *testing.T) {
+	spy := &roundTripperSpy{}
+	req := httptest.NewRequest(http.MethodGet, "https://otherservice.example.com/resource", nil)
+
+	requestid.Transport(spy, noReqId).RoundTrip(req)
+
+	if spy.req != req {
+		t.Error("request should be passed on unchanged")
+	}
+	if got := spy.req.Header.Get("x-dv-request-id"); got != "" {
+		t.Errorf("header should not be set: got %v", got)
+	}
+}
+
+func TestRequestWithRequestIdHeader_Transport_KeepsHeader(t *testing.T) {
+	spy := &roundTripperSpy{}
+	req := httptest.NewRequest(http.MethodGet, "https://otherservice.example.com/resource", nil).WithContext(requestid.Set(context.Background(), "1234"))
+	req.Header.Set("x-dv-request-id", "explicit")
+
+	requestid.Transport(spy).RoundTrip(req)
+
+	if got := spy.req.Header.Get("x-dv-request-id"); got != "explicit" {
+		t.Errorf("wrong header: got %v want %v", got, "explicit")
+	}
+}
+
+func TestTransportAndMiddleware_PropagatesRequestIdToDownstreamService(t *testing.T) {
+	downstream := handlerSpy{}
+	server := httptest.NewServer(requestid.AddToCtx()(&downstream))
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	client := &http.Client{Transport: requestid.Transport(nil)}
+
+	resp, err := client.Do(req.WithContext(requestid.Set(context.Background(), "1234")))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if err := downstream.assertRequestIdIs("1234"); err != nil {
+		t.Error(err)
+	}
+}
+
+type roundTripperSpy struct {
+	req *http.Request
+}
+
+func (s *roundTripperSpy) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.req = req
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func noReqId(context.Context) (string, error) {
+	return "", errors.New("no requestid on context")
+}
+
+func lambdaReqId(reqId string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		return reqId, nil
+	}
+}
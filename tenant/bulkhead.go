@@ -0,0 +1,131 @@
+package tenant
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A BulkheadOption configures the behaviour of Bulkhead.
+type BulkheadOption func(*bulkhead)
+
+// BulkheadQueue allows up to size requests per tenant to wait at most timeout for a free slot
+// if the maximum number of concurrent requests of the tenant is reached. By default such requests are rejected immediately.
+func BulkheadQueue(size int, timeout time.Duration) BulkheadOption {
+	return func(b *bulkhead) {
+		b.queueSize = size
+		b.queueTimeout = timeout
+	}
+}
+
+type compartment struct {
+	slots   chan struct{}
+	waiting int
+	// refs counts the requests which are running or waiting, so that the compartment can be removed if there are none
+	refs int
+}
+
+type bulkhead struct {
+	maxInFlight  int
+	queueSize    int
+	queueTimeout time.Duration
+	// queued is called when a request has been queued. It is used by tests to wait for queued requests.
+	queued func()
+
+	mu           sync.Mutex
+	compartments map[string]*compartment
+}
+
+// Bulkhead limits the number of requests per tenant which are processed concurrently by this instance of the App,
+// so that a tenant with expensive requests can't exhaust the resources for the other tenants.
+//
+// The tenant is determined by IdFromCtx, so Bulkhead must be used after AddToCtx. Requests without a tenant on
+// the context share one limit. If maxInFlight requests of a tenant are running further requests are answered with
+// status 503 unless they can be queued (cf. BulkheadQueue). Bulkhead panics if maxInFlight < 1.
+//
+// Example:
+//	func main() {
+//		mux := http.NewServeMux()
+//		bulkhead := tenant.Bulkhead(10, tenant.BulkheadQueue(20, 5*time.Second))
+//		mux.Handle("/export", tenant.AddToCtx(os.Getenv("systemBaseUri"), signatureSecretKey)(bulkhead(exportHandler())))
+//	}
+func Bulkhead(maxInFlight int, options ...BulkheadOption) func(http.Handler) http.Handler {
+	if maxInFlight < 1 {
+		panic(fmt.Sprintf("tenant: Bulkhead requires maxInFlight >= 1 but got %v", maxInFlight))
+	}
+	b := &bulkhead{maxInFlight: maxInFlight, queued: func() {}, compartments: map[string]*compartment{}}
+	for _, o := range options {
+		o(b)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			tenantId, _ := IdFromCtx(req.Context())
+			c, acquired := b.acquire(req, tenantId)
+			if !acquired {
+				http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			defer b.release(tenantId, c)
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+// acquire returns the compartment of the tenant and whether a slot in the compartment could be acquired for the request.
+func (b *bulkhead) acquire(req *http.Request, tenantId string) (*compartment, bool) {
+	b.mu.Lock()
+	c, ok := b.compartments[tenantId]
+	if !ok {
+		c = &compartment{slots: make(chan struct{}, b.maxInFlight)}
+		b.compartments[tenantId] = c
+	}
+	c.refs++
+	select {
+	case c.slots <- struct{}{}:
+		b.mu.Unlock()
+		return c, true
+	default:
+	}
+	if c.waiting >= b.queueSize {
+		b.unref(tenantId, c)
+		b.mu.Unlock()
+		return c, false
+	}
+	c.waiting++
+	b.mu.Unlock()
+	b.queued()
+
+	timer := time.NewTimer(b.queueTimeout)
+	defer timer.Stop()
+	acquired := false
+	select {
+	case c.slots <- struct{}{}:
+		acquired = true
+	case <-timer.C:
+	case <-req.Context().Done():
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c.waiting--
+	if !acquired {
+		b.unref(tenantId, c)
+	}
+	return c, acquired
+}
+
+func (b *bulkhead) release(tenantId string, c *compartment) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	<-c.slots
+	b.unref(tenantId, c)
+}
+
+// unref must be called with b.mu held
+func (b *bulkhead) unref(tenantId string, c *compartment) {
+	c.refs--
+	if c.refs == 0 {
+		delete(b.compartments, tenantId)
+	}
+}
@@ -0,0 +1,67 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQueuedRequestAndSlotGetsFree_Bulkhead_CallsNextHandler(t *testing.T) {
+	running, release := make(chan struct{}, 1), make(chan struct{})
+	queued := make(chan struct{}, 1)
+	handler := Bulkhead(1, BulkheadQueue(1, time.Minute), notifyQueued(queued))(blockUntil(running, release))
+	first := serveAsync(handler, "a12be5")
+	<-running
+
+	second := serveAsync(handler, "a12be5")
+	<-queued
+	close(release)
+
+	if code := <-second; code != http.StatusOK {
+		t.Errorf("wrong status code: got %v want %v", code, http.StatusOK)
+	}
+	<-first
+}
+
+func TestQueueFull_Bulkhead_Returns503(t *testing.T) {
+	running, release := make(chan struct{}, 1), make(chan struct{})
+	queued := make(chan struct{}, 1)
+	handler := Bulkhead(1, BulkheadQueue(1, time.Minute), notifyQueued(queued))(blockUntil(running, release))
+	first := serveAsync(handler, "a12be5")
+	<-running
+	second := serveAsync(handler, "a12be5")
+	<-queued
+
+	if code := <-serveAsync(handler, "a12be5"); code != http.StatusServiceUnavailable {
+		t.Errorf("wrong status code: got %v want %v", code, http.StatusServiceUnavailable)
+	}
+	close(release)
+	<-first
+	<-second
+}
+
+func notifyQueued(queued chan<- struct{}) BulkheadOption {
+	return func(b *bulkhead) {
+		b.queued = func() { queued <- struct{}{} }
+	}
+}
+
+// blockUntil returns a handler which signals running and blocks until release is closed
+func blockUntil(running chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		running <- struct{}{}
+		<-release
+	})
+}
+
+func serveAsync(handler http.Handler, tenantId string) <-chan int {
+	code := make(chan int, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(SetId(context.Background(), tenantId)))
+		code <- rec.Code
+	}()
+	return code
+}
@@ -0,0 +1,120 @@
+package tenant_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+)
+
+func TestMaxInFlightReached_Bulkhead_Returns503(t *testing.T) {
+	blocking := newBlockingHandler()
+	handler := tenant.Bulkhead(2)(blocking)
+	done := serveInBackground(handler, "a12be5", 2)
+	blocking.waitUntilRunning(t, 2)
+
+	rec := serveForTenant(handler, "a12be5")
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusServiceUnavailable)
+	}
+	blocking.release()
+	done.Wait()
+}
+
+func TestMaxInFlightReachedForOtherTenant_Bulkhead_CallsNextHandler(t *testing.T) {
+	blocking := newBlockingHandler()
+	handler := tenant.Bulkhead(1)(blocking)
+	done := serveInBackground(handler, "a12be5", 1)
+	blocking.waitUntilRunning(t, 1)
+
+	other := serveInBackground(handler, "ff00e1", 1)
+	blocking.waitUntilRunning(t, 1)
+
+	blocking.release()
+	done.Wait()
+	other.Wait()
+}
+
+func TestRequestFinished_Bulkhead_ReleasesSlot(t *testing.T) {
+	handler := tenant.Bulkhead(1)(&handlerSpy{})
+
+	for i := 0; i < 3; i++ {
+		if rec := serveForTenant(handler, "a12be5"); rec.Code != http.StatusOK {
+			t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestQueueTimeout_Bulkhead_Returns503(t *testing.T) {
+	blocking := newBlockingHandler()
+	handler := tenant.Bulkhead(1, tenant.BulkheadQueue(1, 10*time.Millisecond))(blocking)
+	done := serveInBackground(handler, "a12be5", 1)
+	blocking.waitUntilRunning(t, 1)
+
+	rec := serveForTenant(handler, "a12be5")
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusServiceUnavailable)
+	}
+	blocking.release()
+	done.Wait()
+}
+
+func TestMaxInFlightLessThanOne_Bulkhead_Panics(t *testing.T) {
+	for _, maxInFlight := range []int{0, -1} {
+		t.Run(fmt.Sprint(maxInFlight), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+
+			tenant.Bulkhead(maxInFlight)
+		})
+	}
+}
+
+type blockingHandler struct {
+	running chan struct{}
+	done    chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{running: make(chan struct{}, 100), done: make(chan struct{})}
+}
+
+func (h *blockingHandler) ServeHTTP(http.ResponseWriter, *http.Request) {
+	h.running <- struct{}{}
+	<-h.done
+}
+
+func (h *blockingHandler) waitUntilRunning(t *testing.T, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-h.running:
+		case <-time.After(time.Second):
+			t.Fatalf("only %v of %v requests are running", i, n)
+		}
+	}
+}
+
+func (h *blockingHandler) release() {
+	close(h.done)
+}
+
+func serveInBackground(handler http.Handler, tenantId string, n int) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctxWithTenant(tenantId)))
+		}()
+	}
+	return wg
+}
@@ -0,0 +1,224 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// ErrConfigKeyNotFound is returned by Config.Get if the configuration doesn't contain the key.
+var ErrConfigKeyNotFound = errors.New("key not found in tenant configuration")
+
+// Config contains the settings of a tenant like limits, feature choices or external endpoints.
+type Config map[string]interface{}
+
+// Get decodes the value of the key into v, which must be a pointer, like encoding/json would.
+//
+// ErrConfigKeyNotFound is returned if the configuration doesn't contain the key.
+func (c Config) Get(key string, v interface{}) error {
+	value, ok := c[key]
+	if !ok {
+		return ErrConfigKeyNotFound
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error reading tenant configuration '%v' because: %v", key, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("error reading tenant configuration '%v' because: %v", key, err)
+	}
+	return nil
+}
+
+// String returns the value of the key or fallback if the key doesn't exist or is no string.
+func (c Config) String(key string, fallback string) string {
+	var s string
+	if err := c.Get(key, &s); err != nil {
+		return fallback
+	}
+	return s
+}
+
+// Int returns the value of the key or fallback if the key doesn't exist or is no integer.
+func (c Config) Int(key string, fallback int) int {
+	var i int
+	if err := c.Get(key, &i); err != nil {
+		return fallback
+	}
+	return i
+}
+
+// Bool returns the value of the key or fallback if the key doesn't exist or is no boolean.
+func (c Config) Bool(key string, fallback bool) bool {
+	var b bool
+	if err := c.Get(key, &b); err != nil {
+		return fallback
+	}
+	return b
+}
+
+// Duration returns the value of the key or fallback if the key doesn't exist or is no duration
+// in the format of time.ParseDuration like "1m30s".
+func (c Config) Duration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(c.String(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// withDefaults returns a new Config which contains the defaults overwritten by the values of c.
+func (c Config) withDefaults(defaults Config) Config {
+	merged := make(Config, len(defaults)+len(c))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range c {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ConfigProvider provides the configuration of a tenant.
+type ConfigProvider interface {
+	// Config returns the configuration of the tenant. If the tenant has no entry the defaults of the
+	// provider are returned, which might be empty. The returned Config must not be modified.
+	//
+	// An error is returned if something unexpected occurred.
+	Config(ctx context.Context, tenantId string) (Config, error)
+}
+
+// ConfigFromCtx returns the configuration of the tenant from the context as provided by the provider.
+//
+// Example:
+//	func helloHandler(configs tenant.ConfigProvider) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			config, err := tenant.ConfigFromCtx(r.Context(), configs)
+//			if err != nil {
+//				// error handling
+//			}
+//			maxItems := config.Int("maxItems", 100)
+//		})
+//	}
+func ConfigFromCtx(ctx context.Context, provider ConfigProvider) (Config, error) {
+	tenantId, err := IdFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Config(ctx, tenantId)
+}
+
+type inMemoryConfigProvider struct {
+	defaults Config
+	tenants  map[string]Config
+}
+
+// NewInMemoryConfigProvider returns a ConfigProvider for the configurations of the tenants by tenant id.
+//
+// The configuration of a tenant is merged with the defaults, that is keys missing in the configuration of a tenant
+// are taken from the defaults.
+func NewInMemoryConfigProvider(defaults Config, tenants map[string]Config) ConfigProvider {
+	return &inMemoryConfigProvider{defaults: defaults, tenants: tenants}
+}
+
+func (p *inMemoryConfigProvider) Config(_ context.Context, tenantId string) (Config, error) {
+	return p.tenants[tenantId].withDefaults(p.defaults), nil
+}
+
+type jsonFileConfigProvider struct {
+	path string
+}
+
+// NewJsonFileConfigProvider returns a ConfigProvider which reads the configurations from a JSON file.
+//
+// The file contains the defaults and the configurations of the tenants by tenant id:
+//	{
+//		"defaults": {"maxItems": 100, "timeout": "30s"},
+//		"tenants": {
+//			"a12be5": {"maxItems": 1000}
+//		}
+//	}
+//
+// The file is read on each call, so changes take effect immediately. Use NewCachingConfigProvider to reduce
+// the number of reads.
+func NewJsonFileConfigProvider(path string) ConfigProvider {
+	return &jsonFileConfigProvider{path: path}
+}
+
+func (p *jsonFileConfigProvider) Config(_ context.Context, tenantId string) (Config, error) {
+	b, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading tenant configuration file because: %v", err)
+	}
+	var file struct {
+		Defaults Config            `json:"defaults"`
+		Tenants  map[string]Config `json:"tenants"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("error parsing tenant configuration file '%v' because: %v", p.path, err)
+	}
+	return file.Tenants[tenantId].withDefaults(file.Defaults), nil
+}
+
+type cachedConfig struct {
+	config    Config
+	expiresAt time.Time
+}
+
+type cachingConfigProvider struct {
+	next ConfigProvider
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu        sync.Mutex
+	data      map[string]cachedConfig
+	lastPurge time.Time
+}
+
+// NewCachingConfigProvider returns a ConfigProvider which caches the configuration of each tenant
+// provided by next for the given ttl. Errors of next are not cached. Expired configurations are
+// discarded, so the cache doesn't grow with tenants which aren't active anymore.
+func NewCachingConfigProvider(next ConfigProvider, ttl time.Duration) ConfigProvider {
+	return newCachingConfigProvider(next, ttl, time.Now)
+}
+
+func newCachingConfigProvider(next ConfigProvider, ttl time.Duration, now func() time.Time) *cachingConfigProvider {
+	return &cachingConfigProvider{next: next, ttl: ttl, now: now, data: map[string]cachedConfig{}, lastPurge: now()}
+}
+
+func (p *cachingConfigProvider) Config(ctx context.Context, tenantId string) (Config, error) {
+	p.mu.Lock()
+	cached, ok := p.data[tenantId]
+	p.mu.Unlock()
+	if ok && p.now().Before(cached.expiresAt) {
+		return cached.config, nil
+	}
+
+	config, err := p.next.Config(ctx, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	now := p.now()
+	p.purgeExpired(now)
+	p.data[tenantId] = cachedConfig{config: config, expiresAt: now.Add(p.ttl)}
+	p.mu.Unlock()
+	return config, nil
+}
+
+// purgeExpired discards the expired configurations at most once per ttl.
+func (p *cachingConfigProvider) purgeExpired(now time.Time) {
+	if now.Sub(p.lastPurge) < p.ttl {
+		return
+	}
+	for tenantId, cached := range p.data {
+		if !now.Before(cached.expiresAt) {
+			delete(p.data, tenantId)
+		}
+	}
+	p.lastPurge = now
+}
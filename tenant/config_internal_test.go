@@ -0,0 +1,62 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCachingConfigProvider_CachesConfigPerTenantUntilTtlExpires(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	next := &countingConfigProvider{}
+	provider := newCachingConfigProvider(next, time.Minute, clock.Now)
+
+	provider.Config(context.Background(), "a12be5")
+	provider.Config(context.Background(), "a12be5")
+	provider.Config(context.Background(), "ff00e1")
+	if next.calls != 2 {
+		t.Errorf("wrong number of calls: got %v want %v", next.calls, 2)
+	}
+
+	clock.Advance(time.Minute)
+	provider.Config(context.Background(), "a12be5")
+	if next.calls != 3 {
+		t.Errorf("wrong number of calls after ttl: got %v want %v", next.calls, 3)
+	}
+}
+
+func TestExpiredConfigs_CachingConfigProvider_DiscardsConfigsOnWrite(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	provider := newCachingConfigProvider(&countingConfigProvider{}, time.Minute, clock.Now)
+	provider.Config(context.Background(), "a12be5")
+	provider.Config(context.Background(), "ff00e1")
+
+	clock.Advance(time.Minute)
+	provider.Config(context.Background(), "c0ffee")
+
+	if _, ok := provider.data["a12be5"]; ok || len(provider.data) != 1 {
+		t.Errorf("expired configs should have been discarded: got %v", provider.data)
+	}
+}
+
+type countingConfigProvider struct {
+	calls int
+}
+
+func (p *countingConfigProvider) Config(_ context.Context, _ string) (Config, error) {
+	p.calls++
+	return Config{}, nil
+}
+
+// fakeClock is a clock for tests which only advances on demand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,168 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+)
+
+func TestTenantWithConfig_ConfigFromCtx_ReturnsConfigMergedWithDefaults(t *testing.T) {
+	provider := tenant.NewInMemoryConfigProvider(
+		tenant.Config{"maxItems": 100, "endpoint": "https://default.example.com"},
+		map[string]tenant.Config{"a12be5": {"maxItems": 1000}},
+	)
+
+	config, err := tenant.ConfigFromCtx(ctxWithTenant("a12be5"), provider)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Int("maxItems", 0); got != 1000 {
+		t.Errorf("wrong maxItems: got %v want %v", got, 1000)
+	}
+	if got := config.String("endpoint", ""); got != "https://default.example.com" {
+		t.Errorf("wrong endpoint: got %v want %v", got, "https://default.example.com")
+	}
+}
+
+func TestTenantWithoutConfig_ConfigFromCtx_ReturnsDefaults(t *testing.T) {
+	provider := tenant.NewInMemoryConfigProvider(tenant.Config{"maxItems": 100}, map[string]tenant.Config{"a12be5": {"maxItems": 1000}})
+
+	config, err := tenant.ConfigFromCtx(ctxWithTenant("ff00e1"), provider)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Int("maxItems", 0); got != 100 {
+		t.Errorf("wrong maxItems: got %v want %v", got, 100)
+	}
+}
+
+func TestNoTenantOnCtx_ConfigFromCtx_ReturnsError(t *testing.T) {
+	_, err := tenant.ConfigFromCtx(context.Background(), tenant.NewInMemoryConfigProvider(nil, nil))
+
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestConfig_TypedLookup(t *testing.T) {
+	config := tenant.Config{"name": "acme", "limit": 5, "enabled": true, "timeout": "1m30s", "features": []string{"a", "b"}}
+
+	if got := config.String("name", ""); got != "acme" {
+		t.Errorf("wrong String: got %v", got)
+	}
+	if got := config.Int("limit", 0); got != 5 {
+		t.Errorf("wrong Int: got %v", got)
+	}
+	if got := config.Bool("enabled", false); !got {
+		t.Errorf("wrong Bool: got %v", got)
+	}
+	if got := config.Duration("timeout", 0); got != 90*time.Second {
+		t.Errorf("wrong Duration: got %v", got)
+	}
+	if got := config.Int("name", 42); got != 42 {
+		t.Errorf("wrong fallback for wrong type: got %v", got)
+	}
+	if got := config.String("missing", "fallback"); got != "fallback" {
+		t.Errorf("wrong fallback for missing key: got %v", got)
+	}
+	var features []string
+	if err := config.Get("features", &features); err != nil || len(features) != 2 {
+		t.Errorf("wrong Get: got %v, %v", features, err)
+	}
+	if err := config.Get("missing", &features); !errors.Is(err, tenant.ErrConfigKeyNotFound) {
+		t.Errorf("wrong error: got %v want %v", err, tenant.ErrConfigKeyNotFound)
+	}
+}
+
+func TestJsonFile_JsonFileConfigProvider_ReturnsConfigOfTenant(t *testing.T) {
+	path := writeConfigFile(t, `{"defaults":{"maxItems":100,"timeout":"30s"},"tenants":{"a12be5":{"maxItems":1000}}}`)
+	provider := tenant.NewJsonFileConfigProvider(path)
+
+	config, err := provider.Config(context.Background(), "a12be5")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Int("maxItems", 0); got != 1000 {
+		t.Errorf("wrong maxItems: got %v want %v", got, 1000)
+	}
+	if got := config.Duration("timeout", 0); got != 30*time.Second {
+		t.Errorf("wrong timeout: got %v want %v", got, 30*time.Second)
+	}
+}
+
+func TestInvalidJsonFile_JsonFileConfigProvider_ReturnsError(t *testing.T) {
+	provider := tenant.NewJsonFileConfigProvider(writeConfigFile(t, `{"defaults":`))
+
+	if _, err := provider.Config(context.Background(), "a12be5"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestMissingJsonFile_JsonFileConfigProvider_ReturnsError(t *testing.T) {
+	provider := tenant.NewJsonFileConfigProvider(filepath.Join(os.TempDir(), "doesnotexist", "config.json"))
+
+	if _, err := provider.Config(context.Background(), "a12be5"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestCachingConfigProvider_DoesNotCacheErrors(t *testing.T) {
+	spy := &configProviderSpy{err: errors.New("not available")}
+	provider := tenant.NewCachingConfigProvider(spy, time.Minute)
+
+	provider.Config(context.Background(), "a12be5")
+	spy.err = nil
+	config, err := provider.Config(context.Background(), "a12be5")
+
+	if err != nil || config == nil || spy.calls != 2 {
+		t.Errorf("error should not be cached: got %v, %v after %v calls", config, err, spy.calls)
+	}
+}
+
+type configProviderSpy struct {
+	calls int
+	err   error
+}
+
+func (s *configProviderSpy) Config(_ context.Context, tenantId string) (tenant.Config, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return tenant.Config{"tenant": tenantId}, nil
+}
+
+func ctxWithTenant(tenantId string) context.Context {
+	var ctx context.Context
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(tenantIdHeader, tenantId)
+	req.Header.Set(systemBaseUriHeader, "https://sample.example.com")
+	req.Header.Set(signatureHeader, base64Signature("https://sample.example.com"+tenantId, signatureKey))
+	tenant.AddToCtx("", signatureKey)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	return ctx
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "tenantconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/d-velop/dvelop-sdk-go/otellog"
+)
+
+const (
+	// ProductionEnvironmentVariable is the name of the environment variable which marks a production environment.
+	// AddToCtx refuses to start in DevelopmentMode if it is set to a value other than false.
+	ProductionEnvironmentVariable = "DV_PRODUCTION"
+	// DevelopmentTenantHeader is the http header which selects the tenant in DevelopmentMode.
+	DevelopmentTenantHeader = "x-dv-dev-tenant-id"
+	// DevelopmentTenantCookie is the cookie which selects the tenant in DevelopmentMode if the DevelopmentTenantHeader is missing.
+	DevelopmentTenantCookie = "dv-dev-tenant-id"
+)
+
+// ErrDevelopmentTenantNotAllowed is the reason of a VerificationError if the tenant selected in DevelopmentMode isn't allowed.
+var ErrDevelopmentTenantNotAllowed = errors.New("development tenant is not allowed")
+
+// DevelopmentTenant is a tenant which can be selected in DevelopmentMode.
+type DevelopmentTenant struct {
+	// Id is the tenant id
+	Id string
+	// SystemBaseUri is the systemBaseUri of the tenant. If it is empty the defaultSystemBaseUri of AddToCtx is used.
+	SystemBaseUri string
+}
+
+// DevelopmentMode allows to select one of the given tenants for requests without tenant headers by the unsigned
+// http header x-dv-dev-tenant-id or the cookie dv-dev-tenant-id. Selections of other tenants are rejected with status 403.
+//
+// It is meant for local development where no gateway signs the tenant headers. Because anyone can select a tenant,
+// AddToCtx logs a warning at startup and panics if the environment variable DV_PRODUCTION is set. Requests for a
+// selected tenant aren't signed by SigningTransport, so that the unsigned selection doesn't become a signed one.
+//
+// Example:
+//	tenant.AddToCtx("https://localhost:8443", nil, tenant.DevelopmentMode(
+//		tenant.DevelopmentTenant{Id: "a12be5"},
+//		tenant.DevelopmentTenant{Id: "ff00e1", SystemBaseUri: "https://other.localhost:8443"},
+//	))
+func DevelopmentMode(tenants ...DevelopmentTenant) Option {
+	return func(c *config) {
+		if c.developmentTenants == nil {
+			c.developmentTenants = map[string]DevelopmentTenant{}
+		}
+		for _, t := range tenants {
+			c.developmentTenants[t.Id] = t
+		}
+	}
+}
+
+// checkDevelopmentMode panics if the development mode is enabled in a production environment
+// and warns that it is enabled otherwise.
+func (c *config) checkDevelopmentMode() {
+	if c.developmentTenants == nil {
+		return
+	}
+	if isProduction() {
+		panic(fmt.Sprintf("tenant: DevelopmentMode must not be enabled in production (environment variable %v is set)", ProductionEnvironmentVariable))
+	}
+	ids := make([]string, 0, len(c.developmentTenants))
+	for id, t := range c.developmentTenants {
+		if t.SystemBaseUri != "" {
+			normalized, err := c.normalizeSystemBaseUri(t.SystemBaseUri)
+			if err != nil {
+				panic(fmt.Sprintf("tenant: invalid SystemBaseUri of DevelopmentTenant '%v': %v", id, err))
+			}
+			t.SystemBaseUri = normalized
+			c.developmentTenants[id] = t
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	log.WithName("TenantDevelopmentModeEnabled").
+		Errorf(context.Background(), "WARNING: tenant DevelopmentMode is enabled. Unsigned selection of the tenants %v is possible. NEVER use this in production!", strings.Join(ids, ", "))
+}
+
+func isProduction() bool {
+	v, ok := os.LookupEnv(ProductionEnvironmentVariable)
+	if !ok {
+		return false
+	}
+	production, err := strconv.ParseBool(v)
+	// values which can't be parsed are considered as production to be on the safe side
+	return err != nil || production
+}
+
+// isDevelopmentTenant reports whether the tenant on the context has been selected in development mode.
+func isDevelopmentTenant(ctx context.Context) bool {
+	selected, _ := ctx.Value(developmentTenantCtxKey).(bool)
+	return selected
+}
+
+// selectedDevelopmentTenant returns the id of the tenant selected by the request in development mode or an empty string.
+func (c *config) selectedDevelopmentTenant(req *http.Request) string {
+	if c.developmentTenants == nil {
+		return ""
+	}
+	if selected := req.Header.Get(DevelopmentTenantHeader); selected != "" {
+		return selected
+	}
+	if cookie, err := req.Cookie(DevelopmentTenantCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package tenant_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	log "github.com/d-velop/dvelop-sdk-go/otellog"
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+)
+
+var developmentTenants = tenant.DevelopmentMode(
+	tenant.DevelopmentTenant{Id: "a12be5"},
+	tenant.DevelopmentTenant{Id: "ff00e1", SystemBaseUri: "https://Other.localhost:8443/"},
+)
+
+func TestDevelopmentModeAndTenantHeader_AddToCtx_UsesSelectedTenant(t *testing.T) {
+	muteLog()
+	testcases := map[string]struct {
+		selectTenant      func(req *http.Request)
+		wantTenantId      string
+		wantSystemBaseUri string
+	}{
+		"Header": {func(req *http.Request) { req.Header.Set("x-dv-dev-tenant-id", "a12be5") }, "a12be5", defaultSystemBaseUri},
+		"Cookie": {func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "dv-dev-tenant-id", Value: "ff00e1"}) }, "ff00e1", "https://other.localhost:8443"},
+		"HeaderBeforeCookie": {func(req *http.Request) {
+			req.Header.Set("x-dv-dev-tenant-id", "a12be5")
+			req.AddCookie(&http.Cookie{Name: "dv-dev-tenant-id", Value: "ff00e1"})
+		}, "a12be5", defaultSystemBaseUri},
+		"NoSelection": {func(req *http.Request) {}, "0", defaultSystemBaseUri},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+			tc.selectTenant(req)
+			handlerSpy := handlerSpy{}
+
+			tenant.AddToCtx(defaultSystemBaseUri, nil, developmentTenants)(&handlerSpy).ServeHTTP(httptest.NewRecorder(), req)
+
+			if err := handlerSpy.assertTenantIdIs(tc.wantTenantId); err != nil {
+				t.Error(err)
+			}
+			if err := handlerSpy.assertBaseUriIs(tc.wantSystemBaseUri); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDevelopmentModeAndTenantNotInAllowlist_AddToCtx_Returns403(t *testing.T) {
+	muteLog()
+	req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+	req.Header.Set("x-dv-dev-tenant-id", "b00000")
+	var got error
+	errorHandler := func(rw http.ResponseWriter, req *http.Request, err *tenant.VerificationError) {
+		got = err
+		tenant.PlainTextErrorHandler(rw, req, err)
+	}
+	handlerSpy := handlerSpy{}
+	rec := httptest.NewRecorder()
+
+	tenant.AddToCtx(defaultSystemBaseUri, nil, developmentTenants, tenant.ErrorHandler(errorHandler))(&handlerSpy).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusForbidden)
+	}
+	if !errors.Is(got, tenant.ErrDevelopmentTenantNotAllowed) {
+		t.Errorf("wrong error: got %v want %v", got, tenant.ErrDevelopmentTenantNotAllowed)
+	}
+	if handlerSpy.hasBeenCalled {
+		t.Error("inner handler should not have been called")
+	}
+}
+
+func TestDevelopmentModeAndSignedHeaders_AddToCtx_UsesSignedHeaders(t *testing.T) {
+	muteLog()
+	req := signedRequest("https://sample.example.com", "c0ffee", base64Signature("https://sample.example.comc0ffee", signatureKey))
+	req.Header.Set("x-dv-dev-tenant-id", "a12be5")
+	handlerSpy := handlerSpy{}
+
+	tenant.AddToCtx(defaultSystemBaseUri, signatureKey, developmentTenants)(&handlerSpy).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := handlerSpy.assertTenantIdIs("c0ffee"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithoutDevelopmentMode_AddToCtx_IgnoresTenantSelection(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+	req.Header.Set("x-dv-dev-tenant-id", "a12be5")
+	handlerSpy := handlerSpy{}
+
+	tenant.AddToCtx(defaultSystemBaseUri, nil)(&handlerSpy).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := handlerSpy.assertTenantIdIs("0"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDevelopmentMode_AddToCtx_LogsWarning(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log.Default().Reset()
+	log.SetOutput(buf)
+	defer log.Default().Reset()
+
+	tenant.AddToCtx(defaultSystemBaseUri, nil, developmentTenants)
+
+	if !strings.Contains(buf.String(), "TenantDevelopmentModeEnabled") || !strings.Contains(buf.String(), "a12be5, ff00e1") {
+		t.Errorf("expected warning but got %v", buf.String())
+	}
+}
+
+func TestDevelopmentModeInProduction_AddToCtx_Panics(t *testing.T) {
+	for _, value := range []string{"true", "1", "yes"} {
+		t.Run(value, func(t *testing.T) {
+			defer setEnv(t, tenant.ProductionEnvironmentVariable, value)()
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+
+			tenant.AddToCtx(defaultSystemBaseUri, nil, developmentTenants)
+		})
+	}
+}
+
+func TestDevelopmentModeAndProductionFlagFalse_AddToCtx_Starts(t *testing.T) {
+	muteLog()
+	defer setEnv(t, tenant.ProductionEnvironmentVariable, "false")()
+
+	tenant.AddToCtx(defaultSystemBaseUri, nil, developmentTenants)
+}
+
+func TestProductionFlagWithoutDevelopmentMode_AddToCtx_Starts(t *testing.T) {
+	defer setEnv(t, tenant.ProductionEnvironmentVariable, "true")()
+
+	tenant.AddToCtx(defaultSystemBaseUri, nil)
+}
+
+// setEnv sets the environment variable and returns a function which restores the previous value
+func setEnv(t *testing.T, key, value string) func() {
+	previous, wasSet := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if wasSet {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	log "github.com/d-velop/dvelop-sdk-go/otellog"
+)
+
+var (
+	// ErrSignatureKeyMissing is the reason of a VerificationError if no signature key has been configured.
+	ErrSignatureKeyMissing = errors.New("signature secret key has not been configured")
+	// ErrSignatureMalformed is the reason of a VerificationError if the signature header is no valid base64 data.
+	ErrSignatureMalformed = errors.New("signature is not base64 encoded")
+	// ErrSignatureInvalid is the reason of a VerificationError if the signature doesn't match the tenant headers.
+	ErrSignatureInvalid = errors.New("signature is not valid for the tenant headers")
+)
+
+// VerificationError describes why the tenant of a request couldn't be verified.
+//
+// It never contains secret material like signature keys or signatures, so it can safely be logged or
+// returned to the caller.
+type VerificationError struct {
+	// TenantId is the unverified tenant id from the request
+	TenantId string
+	// SystemBaseUri is the unverified systemBaseUri from the request
+	SystemBaseUri string
+	// StatusCode is the http status code which should be returned to the caller
+	StatusCode int
+	// Reason is the cause of the failure like ErrSignatureInvalid. Use errors.Is to check for a specific reason.
+	Reason error
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("verification of tenant '%v' with SystemBaseUri '%v' failed because: %v", e.TenantId, e.SystemBaseUri, e.Reason)
+}
+
+// Unwrap returns the reason of the failure
+func (e *VerificationError) Unwrap() error {
+	return e.Reason
+}
+
+// An ErrorHandlerFunc writes the response for a request whose tenant couldn't be verified.
+type ErrorHandlerFunc func(rw http.ResponseWriter, req *http.Request, err *VerificationError)
+
+// A LogFunc logs a failed verification of the tenant of a request.
+type LogFunc func(ctx context.Context, err *VerificationError)
+
+// ErrorHandler sets the function which writes the response if the tenant of a request couldn't be verified.
+//
+// The default writes the status text of the VerificationError's StatusCode as plain text.
+// Use ProblemJsonErrorHandler to answer with application/problem+json.
+func ErrorHandler(f ErrorHandlerFunc) Option {
+	return func(c *config) {
+		c.errorHandler = f
+	}
+}
+
+// Logger sets the function which logs failed verifications of the tenant of a request.
+//
+// The default logs an otellog event named TenantVerificationFailed which contains the tenant id and the reason
+// as attribute. Failures due to a missing configuration are logged with severity error, all others with severity info.
+func Logger(f LogFunc) Option {
+	return func(c *config) {
+		c.log = f
+	}
+}
+
+// PlainTextErrorHandler answers with the status text of the VerificationError's StatusCode as plain text.
+func PlainTextErrorHandler(rw http.ResponseWriter, _ *http.Request, err *VerificationError) {
+	http.Error(rw, http.StatusText(err.StatusCode), err.StatusCode)
+}
+
+// ProblemJsonErrorHandler answers with a problem details object as defined in RFC 7807 (https://tools.ietf.org/html/rfc7807).
+func ProblemJsonErrorHandler(rw http.ResponseWriter, _ *http.Request, err *VerificationError) {
+	rw.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(err.StatusCode)
+	_ = json.NewEncoder(rw).Encode(struct {
+		Type   string `json:"type"`
+		Title  string `json:"title"`
+		Status int    `json:"status"`
+		Detail string `json:"detail,omitempty"`
+	}{
+		Type:   "about:blank",
+		Title:  http.StatusText(err.StatusCode),
+		Status: err.StatusCode,
+		Detail: err.Reason.Error(),
+	})
+}
+
+type verificationFailedAttributes struct {
+	Tenant struct {
+		Reason string `json:"reason"`
+	} `json:"tenant"`
+}
+
+// logWithOtellog is the default LogFunc
+func logWithOtellog(ctx context.Context, err *VerificationError) {
+	var attr verificationFailedAttributes
+	attr.Tenant.Reason = err.Reason.Error()
+	l := log.WithName("TenantVerificationFailed").
+		With(func(e *log.Event) {
+			e.TenantId = err.TenantId
+		}).
+		WithAdditionalAttributes(attr)
+	if err.StatusCode >= http.StatusInternalServerError {
+		l.Errorf(ctx, "%v", err)
+	} else {
+		l.Infof(ctx, "%v", err)
+	}
+}
+
+func (c *config) fail(rw http.ResponseWriter, req *http.Request, err *VerificationError) {
+	c.log(req.Context(), err)
+	c.errorHandler(rw, req, err)
+}
@@ -0,0 +1,133 @@
+package tenant_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/d-velop/dvelop-sdk-go/otellog"
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+)
+
+func TestInvalidSignatureAndCustomErrorHandler_CallsErrorHandler(t *testing.T) {
+	req := signedRequest("https://sample.example.com", "a12be5", base64Signature("wrong data", signatureKey))
+	var got *tenant.VerificationError
+	errorHandler := func(rw http.ResponseWriter, req *http.Request, err *tenant.VerificationError) {
+		got = err
+		rw.WriteHeader(http.StatusTeapot)
+	}
+	responseSpy := responseSpy{httptest.NewRecorder()}
+
+	tenant.AddToCtx("", signatureKey, tenant.ErrorHandler(errorHandler), tenant.Logger(noLog))(&handlerSpy{}).ServeHTTP(responseSpy, req)
+
+	if err := responseSpy.assertStatusCodeIs(http.StatusTeapot); err != nil {
+		t.Error(err)
+	}
+	if got == nil || !errors.Is(got, tenant.ErrSignatureInvalid) || got.TenantId != "a12be5" || got.StatusCode != http.StatusForbidden {
+		t.Errorf("wrong VerificationError: got %v", got)
+	}
+}
+
+func TestVerificationFails_ErrorHandlerGetsReason(t *testing.T) {
+	testcases := map[string]struct {
+		signature    string
+		signatureKey []byte
+		wantReason   error
+	}{
+		"NoSignatureKey":   {signature: base64Signature("https://sample.example.coma12be5", signatureKey), signatureKey: nil, wantReason: tenant.ErrSignatureKeyMissing},
+		"NoBase64":         {signature: "abc+(9-!", signatureKey: signatureKey, wantReason: tenant.ErrSignatureMalformed},
+		"InvalidSignature": {signature: base64Signature("wrong data", signatureKey), signatureKey: signatureKey, wantReason: tenant.ErrSignatureInvalid},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req := signedRequest("https://sample.example.com", "a12be5", tc.signature)
+			var got error
+			errorHandler := func(rw http.ResponseWriter, req *http.Request, err *tenant.VerificationError) {
+				got = err
+			}
+
+			tenant.AddToCtx("", tc.signatureKey, tenant.ErrorHandler(errorHandler), tenant.Logger(noLog))(&handlerSpy{}).ServeHTTP(httptest.NewRecorder(), req)
+
+			if !errors.Is(got, tc.wantReason) {
+				t.Errorf("wrong reason: got %v want %v", got, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestInvalidSignatureAndProblemJsonErrorHandler_ReturnsProblemJson(t *testing.T) {
+	req := signedRequest("https://sample.example.com", "a12be5", base64Signature("wrong data", signatureKey))
+	rec := httptest.NewRecorder()
+
+	tenant.AddToCtx("", signatureKey, tenant.ErrorHandler(tenant.ProblemJsonErrorHandler), tenant.Logger(noLog))(&handlerSpy{}).ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+		t.Errorf("wrong content type: got %v", ct)
+	}
+	var problem map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem["status"] != float64(http.StatusForbidden) || problem["detail"] != tenant.ErrSignatureInvalid.Error() {
+		t.Errorf("wrong problem: got %v", problem)
+	}
+}
+
+func TestInvalidSignature_DefaultLogger_LogsEventWithTenantIdAndReason(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log.Default().Reset()
+	log.SetOutput(buf)
+	defer log.Default().Reset()
+	signature := base64Signature("wrong data", signatureKey)
+	req := signedRequest("https://sample.example.com", "a12be5", signature)
+
+	tenant.AddToCtx("", signatureKey)(&handlerSpy{}).ServeHTTP(httptest.NewRecorder(), req)
+
+	var e log.Event
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("expected otellog event but got %v", buf.String())
+	}
+	if e.Name != "TenantVerificationFailed" || e.TenantId != "a12be5" || e.Severity != log.SeverityInfo {
+		t.Errorf("wrong event: got %v", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"reason":"`+tenant.ErrSignatureInvalid.Error()+`"`) {
+		t.Errorf("event doesn't contain reason: got %v", buf.String())
+	}
+	if strings.Contains(buf.String(), signature) {
+		t.Errorf("event must not contain the signature: got %v", buf.String())
+	}
+}
+
+func TestNoSignatureKey_DefaultLogger_LogsError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log.Default().Reset()
+	log.SetOutput(buf)
+	defer log.Default().Reset()
+	req := signedRequest("https://sample.example.com", "a12be5", base64Signature("https://sample.example.coma12be5", signatureKey))
+
+	tenant.AddToCtx("", nil)(&handlerSpy{}).ServeHTTP(httptest.NewRecorder(), req)
+
+	var e log.Event
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("expected otellog event but got %v", buf.String())
+	}
+	if e.Severity != log.SeverityError {
+		t.Errorf("wrong severity: got %v want %v", e.Severity, log.SeverityError)
+	}
+}
+
+func signedRequest(systemBaseUri string, tenantId string, signature string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+	req.Header.Set(systemBaseUriHeader, systemBaseUri)
+	req.Header.Set(tenantIdHeader, tenantId)
+	req.Header.Set(signatureHeader, signature)
+	return req
+}
+
+func noLog(context.Context, *tenant.VerificationError) {}
@@ -0,0 +1,281 @@
+// Package forwarded parses the http headers which proxies use to disclose information about the original request.
+//
+// The standardized Forwarded header (https://tools.ietf.org/html/rfc7239) as well as the de-facto standard
+// headers X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto are supported.
+//
+// Example:
+//	elements, err := forwarded.Parse(`for=192.0.2.43, for="[2001:db8:cafe::17]:4711";proto=https;host=example.com`)
+//	if err != nil {
+//		// error handling
+//	}
+//	// the last element has been added by the proxy which sent the request
+//	baseUri := elements[len(elements)-1].BaseUri() // https://example.com
+package forwarded
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	forwardedHeader       = "Forwarded"
+	xForwardedForHeader   = "X-Forwarded-For"
+	xForwardedHostHeader  = "X-Forwarded-Host"
+	xForwardedProtoHeader = "X-Forwarded-Proto"
+	defaultProto          = "https"
+)
+
+// ErrSyntax is returned by Parse if the value of the Forwarded header is malformed.
+var ErrSyntax = errors.New("invalid syntax of forwarded header")
+
+// Element contains the information disclosed by one proxy.
+type Element struct {
+	// For identifies the node which made the request to the proxy, e.g. "192.0.2.60" or "[2001:db8:cafe::17]:4711"
+	For string
+	// By identifies the interface of the proxy which received the request
+	By string
+	// Host is the host of the original request as received by the proxy
+	Host string
+	// Proto is the protocol which was used to make the request to the proxy, e.g. "https"
+	Proto string
+}
+
+// BaseUri returns the uri consisting of scheme and host of the original request, e.g. "https://example.com".
+//
+// The scheme defaults to https if the element contains no protocol. An empty string is returned if the element contains
+// no host, if the protocol is neither http nor https or if the host doesn't match the syntax host[:port] of RFC 7230.
+func (e Element) BaseUri() string {
+	if !isValidHost(e.Host) {
+		return ""
+	}
+	proto := strings.ToLower(e.Proto)
+	if proto == "" {
+		proto = defaultProto
+	}
+	if proto != "http" && proto != "https" {
+		return ""
+	}
+	return proto + "://" + e.Host
+}
+
+// isValidHost reports whether host matches uri-host [ ":" port ] as defined by RFC 7230.
+func isValidHost(host string) bool {
+	name, port := host, ""
+	if strings.HasPrefix(host, "[") {
+		end := strings.IndexByte(host, ']')
+		if end < 0 {
+			return false
+		}
+		ip := net.ParseIP(host[1:end])
+		if ip == nil || ip.To4() != nil {
+			return false
+		}
+		name, port = "", host[end+1:]
+		if port != "" && port[0] != ':' {
+			return false
+		}
+	} else if i := strings.LastIndexByte(host, ':'); i >= 0 {
+		name, port = host[:i], host[i:]
+		if name == "" {
+			return false
+		}
+	} else if host == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isRegNameChar(name[i]) && !(name[i] == '%' && i+2 < len(name) && isHex(name[i+1]) && isHex(name[i+2])) {
+			return false
+		}
+	}
+	for i := 1; i < len(port); i++ {
+		if port[i] < '0' || port[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isRegNameChar reports whether c is an unreserved character or a sub-delim as defined by RFC 3986.
+func isRegNameChar(c byte) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("-._~!$&'()*+,;=", c) >= 0
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// FromRequest returns the elements of the Forwarded headers of the request or, if there are none, the elements of the
+// X-Forwarded headers (cf. ParseXForwarded). Each proxy appends an element, so the first element describes the request
+// of the client and the last element has been added by the proxy which sent the request. Only the elements added by
+// trusted proxies should be used, because a client can send arbitrary elements.
+//
+// An error is returned if the Forwarded header is malformed.
+func FromRequest(req *http.Request) ([]Element, error) {
+	if values := req.Header[forwardedHeader]; len(values) > 0 {
+		return Parse(strings.Join(values, ","))
+	}
+	return ParseXForwarded(req.Header), nil
+}
+
+// ParseXForwarded returns the elements described by the headers X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto.
+//
+// Each of these headers can contain a comma separated list. Proxies append their values, so the lists are aligned
+// at their ends, that is the last element consists of the last value of each header.
+func ParseXForwarded(h http.Header) []Element {
+	fors := headerList(h, xForwardedForHeader)
+	hosts := headerList(h, xForwardedHostHeader)
+	protos := headerList(h, xForwardedProtoHeader)
+	n := max(len(fors), max(len(hosts), len(protos)))
+	elements := make([]Element, n)
+	for i := range elements {
+		elements[i] = Element{For: at(fors, i-n+len(fors)), Host: at(hosts, i-n+len(hosts)), Proto: at(protos, i-n+len(protos))}
+	}
+	return elements
+}
+
+// Parse parses the value of a Forwarded header as defined by RFC 7239.
+//
+// The value consists of comma separated elements, each of which consists of semicolon separated pairs like
+// host=example.com. Values can be quoted strings. Parameter names are case-insensitive, unknown parameters
+// and parameters without value are ignored.
+func Parse(value string) ([]Element, error) {
+	var elements []Element
+	p := parser{s: value}
+	for {
+		e, err := p.element()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+		if p.done() {
+			return elements, nil
+		}
+		p.pos++ // skip ','
+	}
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *parser) skipWhitespace() {
+	for !p.done() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// element parses the pairs up to the next ',' or the end of the value
+func (p *parser) element() (Element, error) {
+	var e Element
+	for {
+		p.skipWhitespace()
+		name := strings.ToLower(p.token())
+		p.skipWhitespace()
+		if !p.done() && p.s[p.pos] == '=' {
+			p.pos++
+			p.skipWhitespace()
+			value, err := p.value()
+			if err != nil {
+				return Element{}, err
+			}
+			e.set(name, value)
+			p.skipWhitespace()
+		}
+		if p.done() || p.s[p.pos] == ',' {
+			return e, nil
+		}
+		if p.s[p.pos] != ';' {
+			return Element{}, ErrSyntax
+		}
+		p.pos++ // skip ';'
+	}
+}
+
+func (e *Element) set(name string, value string) {
+	switch name {
+	case "for":
+		e.For = value
+	case "by":
+		e.By = value
+	case "host":
+		e.Host = value
+	case "proto":
+		e.Proto = value
+	}
+}
+
+func (p *parser) token() string {
+	start := p.pos
+	for !p.done() && isTokenChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) value() (string, error) {
+	if p.done() || p.s[p.pos] != '"' {
+		return p.token(), nil
+	}
+	p.pos++ // skip opening quote
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.done() {
+				return "", ErrSyntax
+			}
+			b.WriteByte(p.s[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", ErrSyntax
+}
+
+// isTokenChar reports whether c is allowed in a token as defined by RFC 7230. For compatibility with proxies which
+// don't quote ports and IPv6 addresses ':', '[' and ']' are accepted as well.
+func isTokenChar(c byte) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~:[]", c) >= 0
+}
+
+func headerList(h http.Header, key string) []string {
+	var list []string
+	for _, v := range h[http.CanonicalHeaderKey(key)] {
+		for _, item := range strings.Split(v, ",") {
+			list = append(list, strings.TrimSpace(item))
+		}
+	}
+	return list
+}
+
+func at(list []string, i int) string {
+	if i >= 0 && i < len(list) {
+		return list[i]
+	}
+	return ""
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
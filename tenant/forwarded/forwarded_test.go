@@ -0,0 +1,122 @@
+package forwarded_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/tenant/forwarded"
+)
+
+func TestParse(t *testing.T) {
+	testcases := []struct {
+		value string
+		want  []forwarded.Element
+	}{
+		{"", []forwarded.Element{{}}},
+		{"host=example.com", []forwarded.Element{{Host: "example.com"}}},
+		{"for=192.0.2.60;proto=http;by=203.0.113.43;host=example.com", []forwarded.Element{{For: "192.0.2.60", Proto: "http", By: "203.0.113.43", Host: "example.com"}}},
+		{"For=192.0.2.60; Proto=HTTP ; Host = example.com", []forwarded.Element{{For: "192.0.2.60", Proto: "HTTP", Host: "example.com"}}},
+		{`for="[2001:db8:cafe::17]:4711";host="example.com:8443"`, []forwarded.Element{{For: "[2001:db8:cafe::17]:4711", Host: "example.com:8443"}}},
+		{`for="_gazonk";host="a\"b"`, []forwarded.Element{{For: "_gazonk", Host: `a"b`}}},
+		{`host="first.example.com;proto=http", host=second.example.com`, []forwarded.Element{{Host: "first.example.com;proto=http"}, {Host: "second.example.com"}}},
+		{"for=192.0.2.43, for=198.51.100.17;proto=http", []forwarded.Element{{For: "192.0.2.43"}, {For: "198.51.100.17", Proto: "http"}}},
+		{"host=forwarded.example.com,secondhost.example.com", []forwarded.Element{{Host: "forwarded.example.com"}, {}}},
+		{"secret=xyz;host=example.com", []forwarded.Element{{Host: "example.com"}}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := forwarded.Parse(tc.value)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("wrong elements: got %+v want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInvalidValue_Parse_ReturnsErrSyntax(t *testing.T) {
+	for _, value := range []string{`host="example.com`, `host="example.com\`, `host=example.com x`, `host=exa/mple.com`} {
+		t.Run(value, func(t *testing.T) {
+			if _, err := forwarded.Parse(value); err != forwarded.ErrSyntax {
+				t.Errorf("wrong error: got %v want %v", err, forwarded.ErrSyntax)
+			}
+		})
+	}
+}
+
+func TestElement_BaseUri(t *testing.T) {
+	testcases := []struct {
+		element forwarded.Element
+		want    string
+	}{
+		{forwarded.Element{Host: "example.com"}, "https://example.com"},
+		{forwarded.Element{Host: "example.com:8080", Proto: "HTTP"}, "http://example.com:8080"},
+		{forwarded.Element{Proto: "http"}, ""},
+		{forwarded.Element{Host: "[2001:db8:cafe::17]:8443"}, "https://[2001:db8:cafe::17]:8443"},
+		{forwarded.Element{Host: "192.0.2.43"}, "https://192.0.2.43"},
+		{forwarded.Element{Host: "ex%41mple.com"}, "https://ex%41mple.com"},
+		{forwarded.Element{Host: "example.com", Proto: "javascript"}, ""},
+		{forwarded.Element{Host: "example.com", Proto: "ftp"}, ""},
+		{forwarded.Element{Host: "example.com/evil"}, ""},
+		{forwarded.Element{Host: "user@example.com"}, ""},
+		{forwarded.Element{Host: "example.com:80a"}, ""},
+		{forwarded.Element{Host: "example.com?q"}, ""},
+		{forwarded.Element{Host: "2001:db8::1"}, ""},
+		{forwarded.Element{Host: "[2001:db8::1"}, ""},
+		{forwarded.Element{Host: "[192.0.2.43]"}, ""},
+		{forwarded.Element{Host: "[2001:db8::1]x"}, ""},
+		{forwarded.Element{Host: ":8080"}, ""},
+		{forwarded.Element{Host: "ex%4gmple.com"}, ""},
+	}
+
+	for _, tc := range testcases {
+		if got := tc.element.BaseUri(); got != tc.want {
+			t.Errorf("wrong BaseUri for %+v: got %v want %v", tc.element, got, tc.want)
+		}
+	}
+}
+
+func TestParseXForwarded(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Forwarded-For", "192.0.2.43, 198.51.100.17")
+	h.Set("X-Forwarded-Host", "example.com")
+	h.Set("X-Forwarded-Proto", "http")
+
+	got := forwarded.ParseXForwarded(h)
+
+	want := []forwarded.Element{{For: "192.0.2.43"}, {For: "198.51.100.17", Host: "example.com", Proto: "http"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong elements: got %+v want %+v", got, want)
+	}
+}
+
+func TestRequestWithForwardedAndXForwardedHeaders_FromRequest_PrefersForwarded(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Add("Forwarded", "host=first.example.com")
+	req.Header.Add("Forwarded", "host=second.example.com")
+	req.Header.Set("X-Forwarded-Host", "x.example.com")
+
+	got, err := forwarded.FromRequest(req)
+
+	want := []forwarded.Element{{Host: "first.example.com"}, {Host: "second.example.com"}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong elements: got %+v, %v want %+v", got, err, want)
+	}
+}
+
+func TestRequestWithXForwardedHeaders_FromRequest_ReturnsXForwardedElements(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-Host", "x.example.com")
+
+	got, err := forwarded.FromRequest(req)
+
+	want := []forwarded.Element{{Host: "x.example.com"}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong elements: got %+v, %v want %+v", got, err, want)
+	}
+}
@@ -1,3 +1,8 @@
 module github.com/d-velop/dvelop-sdk-go/tenant
 
-go 1.12
+require github.com/d-velop/dvelop-sdk-go/otellog v0.0.0-20261018211045-1ec5ae314232
+
+// local development only, replace directives are ignored when this module is required by other modules
+replace github.com/d-velop/dvelop-sdk-go/otellog => ../otellog
+
+go 1.17
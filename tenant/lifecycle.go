@@ -0,0 +1,337 @@
+package tenant
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/d-velop/dvelop-sdk-go/otellog"
+)
+
+// LifecycleEventType is the type of a lifecycle event which is sent by d.velop cloud.
+type LifecycleEventType string
+
+const (
+	// Subscribe is sent if a tenant books the App.
+	Subscribe LifecycleEventType = "subscribe"
+	// Unsubscribe is sent if a tenant cancels the App. The data of the tenant must be kept until the Purge event.
+	Unsubscribe LifecycleEventType = "unsubscribe"
+	// Resubscribe is sent if a tenant books the App again after an Unsubscribe before its data has been purged.
+	Resubscribe LifecycleEventType = "resubscribe"
+	// Purge is sent after an Unsubscribe if all data of the tenant must be deleted.
+	Purge LifecycleEventType = "purge"
+)
+
+const (
+	lifecycleSignatureAlgorithm       = "DV1-HMAC-SHA256"
+	lifecycleSignatureAlgorithmHeader = "x-dv-signature-algorithm"
+	lifecycleSignatureHeadersHeader   = "x-dv-signature-headers"
+	lifecycleSignatureTimestampHeader = "x-dv-signature-timestamp"
+	authorizationHeader               = "Authorization"
+	bearerPrefix                      = "Bearer "
+	// maxLifecycleEventAge is the maximum difference between the signature timestamp and the current time
+	maxLifecycleEventAge  = 5 * time.Minute
+	maxLifecycleEventSize = 64 * 1024
+)
+
+// LifecycleEvent is a notification of d.velop cloud about a change of the subscription of a tenant.
+type LifecycleEvent struct {
+	Type          LifecycleEventType `json:"type"`
+	TenantId      string             `json:"tenantId"`
+	SystemBaseUri string             `json:"baseUri"`
+}
+
+// LifecycleCallbacks are called by LifecycleEventHandler for the corresponding LifecycleEventType.
+//
+// A nil callback means that there is nothing to do for this type of event, the event is recorded in the
+// LifecycleEventStore nevertheless. If a callback returns an error
+// the event is answered with status 500 so that d.velop cloud sends it again. Therefore callbacks should
+// be idempotent.
+type LifecycleCallbacks struct {
+	Subscribe   func(ctx context.Context, event LifecycleEvent) error
+	Unsubscribe func(ctx context.Context, event LifecycleEvent) error
+	Resubscribe func(ctx context.Context, event LifecycleEvent) error
+	Purge       func(ctx context.Context, event LifecycleEvent) error
+}
+
+func (c LifecycleCallbacks) callbackFor(t LifecycleEventType) (func(ctx context.Context, event LifecycleEvent) error, bool) {
+	switch t {
+	case Subscribe:
+		return c.Subscribe, true
+	case Unsubscribe:
+		return c.Unsubscribe, true
+	case Resubscribe:
+		return c.Resubscribe, true
+	case Purge:
+		return c.Purge, true
+	}
+	return nil, false
+}
+
+// LifecycleEventStore remembers the last processed lifecycle event per tenant.
+//
+// It is used by LifecycleEventHandler to skip events which are delivered more than once. Implementations
+// must be safe for concurrent use and should be backed by a persistent storage if the App runs in more
+// than one instance.
+type LifecycleEventStore interface {
+	// LastProcessed returns the type of the last processed event for the tenant or an empty LifecycleEventType if there is none.
+	LastProcessed(ctx context.Context, tenantId string) (LifecycleEventType, error)
+	// CompareAndSetProcessed atomically sets the type of the last processed event for the tenant to new if it is old
+	// and reports whether it has been set, e.g. with a conditional write of the database.
+	CompareAndSetProcessed(ctx context.Context, tenantId string, old LifecycleEventType, new LifecycleEventType) (bool, error)
+}
+
+type inMemoryLifecycleEventStore struct {
+	mu     sync.Mutex
+	events map[string]LifecycleEventType
+}
+
+// NewInMemoryLifecycleEventStore returns a LifecycleEventStore which keeps the processed events in memory.
+//
+// It is suitable for tests and Apps which run in a single instance only.
+func NewInMemoryLifecycleEventStore() LifecycleEventStore {
+	return &inMemoryLifecycleEventStore{events: map[string]LifecycleEventType{}}
+}
+
+func (s *inMemoryLifecycleEventStore) LastProcessed(_ context.Context, tenantId string) (LifecycleEventType, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events[tenantId], nil
+}
+
+func (s *inMemoryLifecycleEventStore) CompareAndSetProcessed(_ context.Context, tenantId string, old LifecycleEventType, new LifecycleEventType) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.events[tenantId] != old {
+		return false, nil
+	}
+	s.events[tenantId] = new
+	return true, nil
+}
+
+// A LifecycleOption configures the behaviour of LifecycleEventHandler.
+type LifecycleOption func(*lifecycleConfig)
+
+type lifecycleConfig struct {
+	store LifecycleEventStore
+	now   func() time.Time
+}
+
+// WithLifecycleEventStore makes LifecycleEventHandler skip events which have already been processed for a tenant.
+//
+// An event is skipped if the last processed event of the tenant has the same type, so a tenant which
+// books the App again after a cancellation is subscribed again. The event is claimed in the store before
+// its callback is called, so concurrent deliveries of the same event call the callback only once.
+// If the callback fails the claim is revoked so that the event is processed again on redelivery.
+func WithLifecycleEventStore(store LifecycleEventStore) LifecycleOption {
+	return func(c *lifecycleConfig) {
+		c.store = store
+	}
+}
+
+// LifecycleEventHandler returns a http.Handler which receives the lifecycle events of d.velop cloud.
+//
+// The handler verifies the signature of the request with the appSecret, which is provided base64 encoded by the
+// registration process for d.velop cloud and must be passed decoded, parses the event and calls the matching callback.
+// Requests with an invalid signature are answered with status 403, malformed events with status 400.
+// The baseUri of the event must be valid like the systemBaseUri of AddToCtx and is normalized the same way.
+// Events of an unknown type are acknowledged without calling a callback.
+//
+// Example:
+//	func main() {
+//		mux := http.NewServeMux()
+//		mux.Handle("/myapp/dvelop-cloud-lifecycle-event", tenant.LifecycleEventHandler(appSecret, tenant.LifecycleCallbacks{
+//			Subscribe: func(ctx context.Context, e tenant.LifecycleEvent) error {
+//				return createTenantStorage(ctx, e.TenantId)
+//			},
+//			Purge: func(ctx context.Context, e tenant.LifecycleEvent) error {
+//				return deleteTenantStorage(ctx, e.TenantId)
+//			},
+//		}, tenant.WithLifecycleEventStore(store)))
+//	}
+func LifecycleEventHandler(appSecret []byte, callbacks LifecycleCallbacks, options ...LifecycleOption) http.Handler {
+	c := &lifecycleConfig{now: time.Now}
+	for _, o := range options {
+		o(c)
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if req.Method != http.MethodPost {
+			rw.Header().Set("Allow", http.MethodPost)
+			http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(rw, req.Body, maxLifecycleEventSize))
+		if err != nil {
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if err := verifyLifecycleEventSignature(req, body, appSecret, c.now()); err != nil {
+			logLifecycleEvent(ctx, LifecycleEvent{}, err)
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		var event LifecycleEvent
+		if err := json.Unmarshal(body, &event); err != nil || event.Type == "" || event.TenantId == "" {
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if event.SystemBaseUri != "" {
+			normalized, err := (&config{}).normalizeSystemBaseUri(event.SystemBaseUri)
+			if err != nil {
+				logLifecycleEvent(ctx, event, err)
+				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			event.SystemBaseUri = normalized
+		}
+		if err := c.dispatch(ctx, callbacks, event); err != nil {
+			logLifecycleEvent(ctx, event, err)
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	})
+}
+
+func (c *lifecycleConfig) dispatch(ctx context.Context, callbacks LifecycleCallbacks, event LifecycleEvent) error {
+	callback, known := callbacks.callbackFor(event.Type)
+	if !known {
+		return nil
+	}
+	if callback == nil {
+		callback = func(context.Context, LifecycleEvent) error { return nil }
+	}
+	if c.store == nil {
+		return callback(ctx, event)
+	}
+	last, err := c.store.LastProcessed(ctx, event.TenantId)
+	if err != nil {
+		return fmt.Errorf("error reading last processed lifecycle event because: %v", err)
+	}
+	if last == event.Type {
+		return nil
+	}
+	claimed, err := c.store.CompareAndSetProcessed(ctx, event.TenantId, last, event.Type)
+	if err != nil {
+		return fmt.Errorf("error storing processed lifecycle event because: %v", err)
+	}
+	if !claimed {
+		// another delivery changed the state concurrently. Let d.velop cloud deliver the event again
+		// which is skipped if the other delivery has processed the same event.
+		return fmt.Errorf("lifecycle event of type '%v' is processed concurrently", event.Type)
+	}
+	if err := callback(ctx, event); err != nil {
+		if _, rErr := c.store.CompareAndSetProcessed(ctx, event.TenantId, event.Type, last); rErr != nil {
+			return fmt.Errorf("%v and error revoking processed lifecycle event because: %v", err, rErr)
+		}
+		return err
+	}
+	return nil
+}
+
+type lifecycleEventAttributes struct {
+	Tenant struct {
+		Event  LifecycleEventType `json:"event,omitempty"`
+		Reason string             `json:"reason"`
+	} `json:"tenant"`
+}
+
+func logLifecycleEvent(ctx context.Context, event LifecycleEvent, err error) {
+	var attr lifecycleEventAttributes
+	attr.Tenant.Event = event.Type
+	attr.Tenant.Reason = err.Error()
+	log.WithName("TenantLifecycleEventFailed").
+		With(func(e *log.Event) {
+			e.TenantId = event.TenantId
+		}).
+		WithAdditionalAttributes(attr).
+		Errorf(ctx, "processing of lifecycle event failed because: %v", err)
+}
+
+// SignLifecycleEvent signs the request with the body like d.velop cloud signs lifecycle events.
+//
+// It sets the headers x-dv-signature-algorithm, x-dv-signature-headers, x-dv-signature-timestamp and Authorization
+// and can be used to emulate d.velop cloud in tests.
+func SignLifecycleEvent(req *http.Request, body []byte, appSecret []byte, timestamp time.Time) {
+	req.Header.Set(lifecycleSignatureAlgorithmHeader, lifecycleSignatureAlgorithm)
+	req.Header.Set(lifecycleSignatureHeadersHeader, strings.Join([]string{lifecycleSignatureAlgorithmHeader, lifecycleSignatureHeadersHeader, lifecycleSignatureTimestampHeader}, ","))
+	req.Header.Set(lifecycleSignatureTimestampHeader, timestamp.UTC().Format(time.RFC3339))
+	req.Header.Set(authorizationHeader, bearerPrefix+hex.EncodeToString(mac([]byte(lifecycleStringToSign(req, body)), appSecret)))
+}
+
+func verifyLifecycleEventSignature(req *http.Request, body []byte, appSecret []byte, now time.Time) error {
+	if len(appSecret) == 0 {
+		return errors.New("app secret has not been configured")
+	}
+	if req.Header.Get(lifecycleSignatureAlgorithmHeader) != lifecycleSignatureAlgorithm {
+		return fmt.Errorf("unsupported signature algorithm '%v'", req.Header.Get(lifecycleSignatureAlgorithmHeader))
+	}
+	if !containsHeader(req.Header.Get(lifecycleSignatureHeadersHeader), lifecycleSignatureTimestampHeader) {
+		return errors.New("signature doesn't cover the timestamp")
+	}
+	timestamp, err := time.Parse(time.RFC3339, req.Header.Get(lifecycleSignatureTimestampHeader))
+	if err != nil {
+		return errors.New("signature timestamp is malformed")
+	}
+	if age := now.Sub(timestamp); age > maxLifecycleEventAge || age < -maxLifecycleEventAge {
+		return fmt.Errorf("signature timestamp %v is outside of the accepted range", timestamp.Format(time.RFC3339))
+	}
+	authorization := req.Header.Get(authorizationHeader)
+	if !strings.HasPrefix(authorization, bearerPrefix) {
+		return errors.New("signature is missing")
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(authorization, bearerPrefix))
+	if err != nil {
+		return errors.New("signature is not hex encoded")
+	}
+	if !hmac.Equal(signature, mac([]byte(lifecycleStringToSign(req, body)), appSecret)) {
+		return errors.New("signature is not valid")
+	}
+	return nil
+}
+
+// lifecycleStringToSign returns the hex encoded hash of the canonical request, which consists of method, path, query,
+// the signed headers and the hash of the body.
+func lifecycleStringToSign(req *http.Request, body []byte) string {
+	var signedHeaders []string
+	for _, h := range strings.Split(req.Header.Get(lifecycleSignatureHeadersHeader), ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			signedHeaders = append(signedHeaders, h)
+		}
+	}
+	sort.Strings(signedHeaders)
+	var canonicalHeaders strings.Builder
+	for _, h := range signedHeaders {
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(req.Header.Get(h)) + "\n")
+	}
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	return hex.EncodeToString(hash[:])
+}
+
+func containsHeader(list string, header string) bool {
+	for _, h := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(h), header) {
+			return true
+		}
+	}
+	return false
+}
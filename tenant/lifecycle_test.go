@@ -0,0 +1,318 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/d-velop/dvelop-sdk-go/otellog"
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+)
+
+var appSecret = []byte("Rg9iJXX0Jkun9u4Rp6no8HTNEdHlfX9aZYbFJ9b6YdQ=")
+
+const subscribeEvent = `{"type":"subscribe","tenantId":"a12be5","baseUri":"https://sample.example.com"}`
+
+func TestSignedSubscribeEvent_LifecycleEventHandler_CallsSubscribe(t *testing.T) {
+	callbacks := &callbackSpy{}
+	rec := httptest.NewRecorder()
+
+	tenant.LifecycleEventHandler(appSecret, callbacks.callbacks()).ServeHTTP(rec, lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusOK)
+	}
+	want := tenant.LifecycleEvent{Type: tenant.Subscribe, TenantId: "a12be5", SystemBaseUri: "https://sample.example.com"}
+	if len(callbacks.events) != 1 || callbacks.events[0] != want {
+		t.Errorf("wrong events: got %v want %v", callbacks.events, want)
+	}
+}
+
+func TestBaseUriWithUppercaseHostAndSlash_LifecycleEventHandler_NormalizesBaseUri(t *testing.T) {
+	callbacks := &callbackSpy{}
+
+	tenant.LifecycleEventHandler(appSecret, callbacks.callbacks()).ServeHTTP(httptest.NewRecorder(), lifecycleRequest(`{"type":"subscribe","tenantId":"a12be5","baseUri":"https://Sample.Example.com/"}`, appSecret, time.Now()))
+
+	if len(callbacks.events) != 1 || callbacks.events[0].SystemBaseUri != "https://sample.example.com" {
+		t.Errorf("wrong events: got %v want baseUri %v", callbacks.events, "https://sample.example.com")
+	}
+}
+
+func TestSignedEvent_LifecycleEventHandler_DispatchesToCallbackForType(t *testing.T) {
+	testcases := []struct {
+		body string
+		want []tenant.LifecycleEventType
+	}{
+		{`{"type":"subscribe","tenantId":"a12be5","baseUri":"https://sample.example.com"}`, []tenant.LifecycleEventType{tenant.Subscribe}},
+		{`{"type":"unsubscribe","tenantId":"a12be5","baseUri":"https://sample.example.com"}`, []tenant.LifecycleEventType{tenant.Unsubscribe}},
+		{`{"type":"resubscribe","tenantId":"a12be5","baseUri":"https://sample.example.com"}`, []tenant.LifecycleEventType{tenant.Resubscribe}},
+		{`{"type":"purge","tenantId":"a12be5","baseUri":"https://sample.example.com"}`, []tenant.LifecycleEventType{tenant.Purge}},
+		{`{"type":"somethingnew","tenantId":"a12be5","baseUri":"https://sample.example.com"}`, nil},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.body, func(t *testing.T) {
+			callbacks := &callbackSpy{}
+			rec := httptest.NewRecorder()
+
+			tenant.LifecycleEventHandler(appSecret, callbacks.callbacks()).ServeHTTP(rec, lifecycleRequest(tc.body, appSecret, time.Now()))
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusOK)
+			}
+			if got := callbacks.types(); !equalTypes(got, tc.want) {
+				t.Errorf("wrong callbacks: got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNilCallback_LifecycleEventHandler_AcknowledgesEvent(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	tenant.LifecycleEventHandler(appSecret, tenant.LifecycleCallbacks{}).ServeHTTP(rec, lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusOK)
+	}
+}
+
+func TestInvalidRequest_LifecycleEventHandler_RejectsEventWithoutCallingCallback(t *testing.T) {
+	muteLog()
+	testcases := map[string]struct {
+		req        func() *http.Request
+		wantStatus int
+	}{
+		"WrongSecret": {func() *http.Request {
+			return lifecycleRequest(subscribeEvent, []byte("wrong"), time.Now())
+		}, http.StatusForbidden},
+		"ExpiredTimestamp": {func() *http.Request {
+			return lifecycleRequest(subscribeEvent, appSecret, time.Now().Add(-10*time.Minute))
+		}, http.StatusForbidden},
+		"TimestampInFuture": {func() *http.Request {
+			return lifecycleRequest(subscribeEvent, appSecret, time.Now().Add(10*time.Minute))
+		}, http.StatusForbidden},
+		"ModifiedBody": {func() *http.Request {
+			req := lifecycleRequest(subscribeEvent, appSecret, time.Now())
+			req.Body = ioutil.NopCloser(strings.NewReader(strings.Replace(subscribeEvent, "subscribe", "purge", 1)))
+			return req
+		}, http.StatusForbidden},
+		"NoAuthorization": {func() *http.Request {
+			req := lifecycleRequest(subscribeEvent, appSecret, time.Now())
+			req.Header.Del("Authorization")
+			return req
+		}, http.StatusForbidden},
+		"TimestampNotSigned": {func() *http.Request {
+			req := lifecycleRequest(subscribeEvent, appSecret, time.Now())
+			req.Header.Set("x-dv-signature-headers", "x-dv-signature-algorithm")
+			return req
+		}, http.StatusForbidden},
+		"MalformedJson": {func() *http.Request {
+			return lifecycleRequest(`{"type":`, appSecret, time.Now())
+		}, http.StatusBadRequest},
+		"NoTenantId": {func() *http.Request {
+			return lifecycleRequest(`{"type":"subscribe","baseUri":"https://sample.example.com"}`, appSecret, time.Now())
+		}, http.StatusBadRequest},
+		"HttpBaseUri": {func() *http.Request {
+			return lifecycleRequest(`{"type":"subscribe","tenantId":"a12be5","baseUri":"http://sample.example.com"}`, appSecret, time.Now())
+		}, http.StatusBadRequest},
+		"BaseUriWithPath": {func() *http.Request {
+			return lifecycleRequest(`{"type":"subscribe","tenantId":"a12be5","baseUri":"https://sample.example.com/evil"}`, appSecret, time.Now())
+		}, http.StatusBadRequest},
+		"WrongMethod": {func() *http.Request {
+			req := lifecycleRequest(subscribeEvent, appSecret, time.Now())
+			req.Method = http.MethodGet
+			return req
+		}, http.StatusMethodNotAllowed},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			callbacks := &callbackSpy{}
+			rec := httptest.NewRecorder()
+
+			tenant.LifecycleEventHandler(appSecret, callbacks.callbacks()).ServeHTTP(rec, tc.req())
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("wrong status code: got %v want %v", rec.Code, tc.wantStatus)
+			}
+			if len(callbacks.events) != 0 {
+				t.Errorf("callback must not be called but got %v", callbacks.events)
+			}
+		})
+	}
+}
+
+func TestCallbackFails_LifecycleEventHandler_Returns500(t *testing.T) {
+	muteLog()
+	callbacks := &callbackSpy{err: errors.New("storage not available")}
+	rec := httptest.NewRecorder()
+
+	tenant.LifecycleEventHandler(appSecret, callbacks.callbacks()).ServeHTTP(rec, lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestDuplicateEventAndStore_LifecycleEventHandler_CallsCallbackOnce(t *testing.T) {
+	callbacks := &callbackSpy{}
+	handler := tenant.LifecycleEventHandler(appSecret, callbacks.callbacks(), tenant.WithLifecycleEventStore(tenant.NewInMemoryLifecycleEventStore()))
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+		if rec.Code != http.StatusOK {
+			t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusOK)
+		}
+	}
+
+	if len(callbacks.events) != 1 {
+		t.Errorf("callback should be called once but got %v", callbacks.events)
+	}
+}
+
+func TestDuplicateEventDuringCallbackAndStore_LifecycleEventHandler_CallsCallbackOnce(t *testing.T) {
+	var handler http.Handler
+	var calls int
+	var duplicateStatus int
+	subscribe := func(_ context.Context, _ tenant.LifecycleEvent) error {
+		calls++
+		if calls == 1 {
+			// the duplicate is delivered while the first delivery is still processed
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+			duplicateStatus = rec.Code
+		}
+		return nil
+	}
+	handler = tenant.LifecycleEventHandler(appSecret, tenant.LifecycleCallbacks{Subscribe: subscribe}, tenant.WithLifecycleEventStore(tenant.NewInMemoryLifecycleEventStore()))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+
+	if rec.Code != http.StatusOK || duplicateStatus != http.StatusOK {
+		t.Errorf("wrong status codes: got %v and %v want %v", rec.Code, duplicateStatus, http.StatusOK)
+	}
+	if calls != 1 {
+		t.Errorf("callback should be called once but got %v calls", calls)
+	}
+}
+
+func TestStoreChangedConcurrently_LifecycleEventHandler_Returns500WithoutCallingCallback(t *testing.T) {
+	muteLog()
+	callbacks := &callbackSpy{}
+	store := &racingStore{LifecycleEventStore: tenant.NewInMemoryLifecycleEventStore()}
+	handler := tenant.LifecycleEventHandler(appSecret, callbacks.callbacks(), tenant.WithLifecycleEventStore(store))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusInternalServerError)
+	}
+	if len(callbacks.events) != 0 {
+		t.Errorf("callback must not be called but got %v", callbacks.events)
+	}
+}
+
+func TestResubscribeAndStore_LifecycleEventHandler_CallsSubscribeAgain(t *testing.T) {
+	callbacks := &callbackSpy{}
+	handler := tenant.LifecycleEventHandler(appSecret, callbacks.callbacks(), tenant.WithLifecycleEventStore(tenant.NewInMemoryLifecycleEventStore()))
+
+	handler.ServeHTTP(httptest.NewRecorder(), lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+	handler.ServeHTTP(httptest.NewRecorder(), lifecycleRequest(`{"type":"unsubscribe","tenantId":"a12be5"}`, appSecret, time.Now()))
+	handler.ServeHTTP(httptest.NewRecorder(), lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+
+	want := []tenant.LifecycleEventType{tenant.Subscribe, tenant.Unsubscribe, tenant.Subscribe}
+	if got := callbacks.types(); !equalTypes(got, want) {
+		t.Errorf("wrong callbacks: got %v want %v", got, want)
+	}
+}
+
+func TestNilCallbackAndStore_LifecycleEventHandler_RecordsEvent(t *testing.T) {
+	store := tenant.NewInMemoryLifecycleEventStore()
+	handler := tenant.LifecycleEventHandler(appSecret, tenant.LifecycleCallbacks{}, tenant.WithLifecycleEventStore(store))
+
+	handler.ServeHTTP(httptest.NewRecorder(), lifecycleRequest(`{"type":"unsubscribe","tenantId":"a12be5"}`, appSecret, time.Now()))
+
+	if got, err := store.LastProcessed(context.Background(), "a12be5"); err != nil || got != tenant.Unsubscribe {
+		t.Errorf("wrong last processed event: got %v, %v want %v", got, err, tenant.Unsubscribe)
+	}
+}
+
+func TestFailedCallbackAndStore_LifecycleEventHandler_RetriesEvent(t *testing.T) {
+	muteLog()
+	callbacks := &callbackSpy{err: errors.New("storage not available")}
+	handler := tenant.LifecycleEventHandler(appSecret, callbacks.callbacks(), tenant.WithLifecycleEventStore(tenant.NewInMemoryLifecycleEventStore()))
+
+	handler.ServeHTTP(httptest.NewRecorder(), lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+	callbacks.err = nil
+	handler.ServeHTTP(httptest.NewRecorder(), lifecycleRequest(subscribeEvent, appSecret, time.Now()))
+
+	if len(callbacks.events) != 2 {
+		t.Errorf("callback should be called twice but got %v", callbacks.events)
+	}
+}
+
+func lifecycleRequest(body string, secret []byte, timestamp time.Time) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/myapp/dvelop-cloud-lifecycle-event", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	tenant.SignLifecycleEvent(req, []byte(body), secret, timestamp)
+	return req
+}
+
+// racingStore simulates a concurrent delivery of an unsubscribe event between reading and claiming the event
+type racingStore struct {
+	tenant.LifecycleEventStore
+}
+
+func (s *racingStore) LastProcessed(ctx context.Context, tenantId string) (tenant.LifecycleEventType, error) {
+	last, err := s.LifecycleEventStore.LastProcessed(ctx, tenantId)
+	s.LifecycleEventStore.CompareAndSetProcessed(ctx, tenantId, last, tenant.Unsubscribe)
+	return last, err
+}
+
+type callbackSpy struct {
+	events []tenant.LifecycleEvent
+	err    error
+}
+
+func (s *callbackSpy) record(_ context.Context, e tenant.LifecycleEvent) error {
+	s.events = append(s.events, e)
+	return s.err
+}
+
+func (s *callbackSpy) callbacks() tenant.LifecycleCallbacks {
+	return tenant.LifecycleCallbacks{Subscribe: s.record, Unsubscribe: s.record, Resubscribe: s.record, Purge: s.record}
+}
+
+func (s *callbackSpy) types() []tenant.LifecycleEventType {
+	var types []tenant.LifecycleEventType
+	for _, e := range s.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func equalTypes(a, b []tenant.LifecycleEventType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func muteLog() {
+	log.Default().Reset()
+	log.SetOutput(ioutil.Discard)
+}
@@ -0,0 +1,43 @@
+package tenant
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// An Option configures the behaviour of AddToCtx.
+type Option func(*config)
+
+type config struct {
+	signatureKeys []SignatureKey
+	now           func() time.Time
+	errorHandler  ErrorHandlerFunc
+	log           LogFunc
+	// isTrustedProxy decides whether the forwarding headers of the request are used
+	isTrustedProxy func(req *http.Request) bool
+	trustsProxies  bool
+	// proxyHeaders are the forwarding headers which are set by the trusted proxies
+	proxyHeaders    ProxyHeaders
+	allowedHosts    []string
+	tenantIdPattern *regexp.Regexp
+	// developmentTenants are the tenants which can be selected in development mode. nil if the mode is disabled.
+	developmentTenants map[string]DevelopmentTenant
+}
+
+func newConfig(signatureSecretKey []byte, options []Option) *config {
+	c := &config{
+		now:             time.Now,
+		errorHandler:    PlainTextErrorHandler,
+		log:             logWithOtellog,
+		isTrustedProxy:  trustNoProxy,
+		tenantIdPattern: DefaultTenantIdPattern,
+	}
+	if signatureSecretKey != nil {
+		c.signatureKeys = append(c.signatureKeys, SignatureKey{Id: CurrentSignatureKeyId, Key: signatureSecretKey})
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
@@ -0,0 +1,190 @@
+package tenant
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	retryAfterHeader         = "Retry-After"
+	rateLimitLimitHeader     = "RateLimit-Limit"
+	rateLimitRemainingHeader = "RateLimit-Remaining"
+	rateLimitResetHeader     = "RateLimit-Reset"
+	defaultIdleTimeout       = 10 * time.Minute
+)
+
+// Limit is the rate limit of a tenant as token bucket.
+type Limit struct {
+	// Rate is the number of requests per second which are allowed on average. A Rate <= 0 disables the limit.
+	Rate float64
+	// Burst is the maximum number of requests which are allowed at once. A Burst < 1 defaults to Rate rounded up
+	// but at least 1, so a limit without Burst allows the requests of one second at once.
+	Burst int
+}
+
+func (l Limit) isUnlimited() bool {
+	return l.Rate <= 0
+}
+
+func (l Limit) withDefaultBurst() Limit {
+	if l.Burst < 1 {
+		l.Burst = int(math.Max(1, math.Ceil(l.Rate)))
+	}
+	return l
+}
+
+// A RateLimitOption configures the behaviour of RateLimit.
+type RateLimitOption func(*rateLimiter)
+
+// RateLimitOverrides sets limits for particular tenants by tenant id which replace the default limit.
+func RateLimitOverrides(overrides map[string]Limit) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.overrides = overrides
+	}
+}
+
+// RateLimitByPrincipal limits the requests per principal of a tenant instead of per tenant.
+//
+// The id of the principal is read by getPrincipalIdFromCtx, e.g. a function which returns the id of idp.PrincipalFromCtx.
+// Requests for which no principal id can be read share the limit of the tenant.
+func RateLimitByPrincipal(getPrincipalIdFromCtx func(ctx context.Context) (string, error)) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.getPrincipalIdFromCtx = getPrincipalIdFromCtx
+	}
+}
+
+// RateLimitIdleTimeout sets the duration after which the token bucket of an idle tenant is discarded. Default is 10 minutes.
+func RateLimitIdleTimeout(d time.Duration) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.idleTimeout = d
+	}
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+type rateLimiter struct {
+	limit                 Limit
+	overrides             map[string]Limit
+	getPrincipalIdFromCtx func(ctx context.Context) (string, error)
+	idleTimeout           time.Duration
+	now                   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// RateLimit limits the rate of requests per tenant, so that a single tenant can't starve the other tenants.
+//
+// The limits are enforced per instance of the App with a token bucket for each tenant id from IdFromCtx, so
+// RateLimit must be used after AddToCtx. Requests without a tenant on the context share one bucket.
+// Rejected requests are answered with status 429 and a Retry-After header. All responses contain the
+// headers RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset.
+//
+// Example:
+//	func main() {
+//		mux := http.NewServeMux()
+//		limit := tenant.RateLimit(tenant.Limit{Rate: 10, Burst: 20}, tenant.RateLimitOverrides(map[string]tenant.Limit{
+//			"a12be5": {Rate: 100, Burst: 200},
+//		}))
+//		mux.Handle("/hello", tenant.AddToCtx(os.Getenv("systemBaseUri"), signatureSecretKey)(limit(helloHandler())))
+//	}
+func RateLimit(limit Limit, options ...RateLimitOption) func(http.Handler) http.Handler {
+	l := &rateLimiter{limit: limit, idleTimeout: defaultIdleTimeout, now: time.Now, buckets: map[string]*bucket{}}
+	for _, o := range options {
+		o(l)
+	}
+	l.lastSweep = l.now()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			tenantId, _ := IdFromCtx(req.Context())
+			limit := l.limitFor(tenantId)
+			if limit.isUnlimited() {
+				next.ServeHTTP(rw, req)
+				return
+			}
+			allowed, remaining, reset, retryAfter := l.take(l.key(req.Context(), tenantId), limit)
+			rw.Header().Set(rateLimitLimitHeader, strconv.Itoa(limit.Burst))
+			rw.Header().Set(rateLimitRemainingHeader, strconv.Itoa(remaining))
+			rw.Header().Set(rateLimitResetHeader, strconv.Itoa(seconds(reset)))
+			if !allowed {
+				rw.Header().Set(retryAfterHeader, strconv.Itoa(seconds(retryAfter)))
+				http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+func (l *rateLimiter) limitFor(tenantId string) Limit {
+	if limit, ok := l.overrides[tenantId]; ok {
+		return limit.withDefaultBurst()
+	}
+	return l.limit.withDefaultBurst()
+}
+
+func (l *rateLimiter) key(ctx context.Context, tenantId string) string {
+	if l.getPrincipalIdFromCtx == nil {
+		return tenantId
+	}
+	principalId, err := l.getPrincipalIdFromCtx(ctx)
+	if err != nil || principalId == "" {
+		return tenantId
+	}
+	return tenantId + "/" + principalId
+}
+
+// take takes a token from the bucket for the key and returns whether the request is allowed, the number of remaining
+// tokens, the duration until the bucket is full again and, if the request isn't allowed, the duration until the next token is available.
+func (l *rateLimiter) take(key string, limit Limit) (allowed bool, remaining int, reset time.Duration, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.evictIdleBuckets(now)
+	burst := float64(limit.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, lastSeen: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.lastSeen).Seconds()*limit.Rate)
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		allowed = true
+	} else {
+		retryAfter = durationOf((1 - b.tokens) / limit.Rate)
+	}
+	return allowed, int(b.tokens), durationOf((burst - b.tokens) / limit.Rate), retryAfter
+}
+
+func (l *rateLimiter) evictIdleBuckets(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idleTimeout {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func durationOf(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// seconds returns the duration in whole seconds rounded up, as used by the Retry-After and RateLimit-Reset headers.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,31 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdleBucket_RateLimit_EvictsBucket(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	withClock := func(l *rateLimiter) {
+		l.now = clock.Now
+	}
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	handler := RateLimit(Limit{Rate: 1.0 / 3600, Burst: 1}, RateLimitIdleTimeout(time.Minute), withClock)(next)
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(SetId(context.Background(), "a12be5")))
+		return rec.Code
+	}
+	serve()
+	serve()
+
+	clock.Advance(time.Minute)
+
+	if got := serve(); got != http.StatusOK {
+		t.Errorf("bucket should have been evicted: got %v want %v", got, http.StatusOK)
+	}
+}
@@ -0,0 +1,157 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+)
+
+// veryLowRate doesn't refill the bucket during a test
+const veryLowRate = 1.0 / 3600
+
+func TestRequestsWithinBurst_RateLimit_CallsNextHandler(t *testing.T) {
+	handler := tenant.RateLimit(tenant.Limit{Rate: veryLowRate, Burst: 2})(&handlerSpy{})
+
+	for i := 2; i > 0; i-- {
+		rec := serveForTenant(handler, "a12be5")
+		if rec.Code != http.StatusOK {
+			t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("wrong RateLimit-Limit: got %v want %v", got, "2")
+		}
+		if got := rec.Header().Get("RateLimit-Remaining"); got != strconv.Itoa(i-1) {
+			t.Errorf("wrong RateLimit-Remaining: got %v want %v", got, i-1)
+		}
+		if rec.Header().Get("RateLimit-Reset") == "" {
+			t.Error("missing RateLimit-Reset header")
+		}
+	}
+}
+
+func TestRequestsExceedBurst_RateLimit_Returns429WithRetryAfter(t *testing.T) {
+	handlerSpy := &handlerSpy{}
+	handler := tenant.RateLimit(tenant.Limit{Rate: 0.5, Burst: 1})(handlerSpy)
+	serveForTenant(handler, "a12be5")
+	handlerSpy.hasBeenCalled = false
+
+	rec := serveForTenant(handler, "a12be5")
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("wrong Retry-After: got %v want %v", got, "2")
+	}
+	if got := rec.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("wrong RateLimit-Remaining: got %v want %v", got, "0")
+	}
+	if handlerSpy.hasBeenCalled {
+		t.Error("inner handler should not have been called")
+	}
+}
+
+func TestRequestsOfDifferentTenants_RateLimit_LimitsEachTenantSeparately(t *testing.T) {
+	handler := tenant.RateLimit(tenant.Limit{Rate: veryLowRate, Burst: 1})(&handlerSpy{})
+	serveForTenant(handler, "a12be5")
+
+	if rec := serveForTenant(handler, "ff00e1"); rec.Code != http.StatusOK {
+		t.Errorf("wrong status code for other tenant: got %v want %v", rec.Code, http.StatusOK)
+	}
+	if rec := serveForTenant(handler, "a12be5"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestTenantWithOverride_RateLimit_UsesOverride(t *testing.T) {
+	handler := tenant.RateLimit(tenant.Limit{Rate: veryLowRate, Burst: 1}, tenant.RateLimitOverrides(map[string]tenant.Limit{
+		"a12be5": {Rate: veryLowRate, Burst: 3},
+		"ff00e1": {},
+	}))(&handlerSpy{})
+
+	for i := 0; i < 3; i++ {
+		if rec := serveForTenant(handler, "a12be5"); rec.Code != http.StatusOK {
+			t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusOK)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		rec := serveForTenant(handler, "ff00e1")
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("unlimited tenant should not be limited: got %v %v", rec.Code, rec.Header())
+		}
+	}
+}
+
+func TestRateLimitByPrincipal_RateLimit_LimitsEachPrincipalSeparately(t *testing.T) {
+	handler := tenant.RateLimit(tenant.Limit{Rate: veryLowRate, Burst: 1}, tenant.RateLimitByPrincipal(principalIdFromCtx))(&handlerSpy{})
+
+	if rec := serveForTenant(handler, "a12be5", withPrincipal("alice")); rec.Code != http.StatusOK {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusOK)
+	}
+	if rec := serveForTenant(handler, "a12be5", withPrincipal("bob")); rec.Code != http.StatusOK {
+		t.Errorf("wrong status code for other principal: got %v want %v", rec.Code, http.StatusOK)
+	}
+	if rec := serveForTenant(handler, "a12be5", withPrincipal("alice")); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestLimitWithoutBurst_RateLimit_AllowsRequestsOfOneSecondAtOnce(t *testing.T) {
+	testcases := []struct {
+		limit     tenant.Limit
+		wantBurst int
+	}{
+		{tenant.Limit{Rate: 10}, 10},
+		{tenant.Limit{Rate: 2.5}, 3},
+		{tenant.Limit{Rate: veryLowRate}, 1},
+		{tenant.Limit{Rate: 10, Burst: -1}, 10},
+	}
+
+	for _, tc := range testcases {
+		t.Run(fmt.Sprint(tc.limit), func(t *testing.T) {
+			handler := tenant.RateLimit(tc.limit)(&handlerSpy{})
+
+			for i := 0; i < tc.wantBurst; i++ {
+				rec := serveForTenant(handler, "a12be5")
+				if rec.Code != http.StatusOK {
+					t.Fatalf("request %v: wrong status code: got %v want %v", i, rec.Code, http.StatusOK)
+				}
+				if got := rec.Header().Get("RateLimit-Limit"); got != strconv.Itoa(tc.wantBurst) {
+					t.Errorf("wrong RateLimit-Limit: got %v want %v", got, tc.wantBurst)
+				}
+			}
+		})
+	}
+}
+
+type principalIdCtxKey struct{}
+
+func principalIdFromCtx(ctx context.Context) (string, error) {
+	id, ok := ctx.Value(principalIdCtxKey{}).(string)
+	if !ok {
+		return "", errors.New("no principal on context")
+	}
+	return id, nil
+}
+
+func withPrincipal(id string) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, principalIdCtxKey{}, id)
+	}
+}
+
+func serveForTenant(handler http.Handler, tenantId string, decorators ...func(context.Context) context.Context) *httptest.ResponseRecorder {
+	ctx := ctxWithTenant(tenantId)
+	for _, d := range decorators {
+		ctx = d(ctx)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	return rec
+}
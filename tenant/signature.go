@@ -0,0 +1,97 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"time"
+)
+
+// CurrentSignatureKeyId is the id of the signatureSecretKey which is passed to AddToCtx directly.
+const CurrentSignatureKeyId = "current"
+
+// SignatureKey is a secret key which is accepted for the verification of the tenant headers.
+type SignatureKey struct {
+	// Id identifies the key. It is put on the context if the key verified the signature of a request (cf. SignatureKeyIdFromCtx)
+	// and MUST NOT contain secret material.
+	Id string
+	// Key is the secret signature key as provided by the registration process for d.velop cloud.
+	Key []byte
+	// ExpiresAt is the point in time from which the key is no longer accepted. The zero value means the key never expires.
+	ExpiresAt time.Time
+}
+
+func (k SignatureKey) isExpired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// SignatureKeys adds further keys which are accepted for the verification of the tenant headers
+// in addition to the signatureSecretKey passed to AddToCtx.
+//
+// This allows the rotation of the signature secret without downtime. During the rotation the previous
+// key is accepted as well, optionally until it expires. The signatureSecretKey of AddToCtx may be nil if
+// all keys are provided by this option.
+//
+// Example:
+//	tenant.AddToCtx(defaultSystemBaseUri, newSecret, tenant.SignatureKeys(
+//		tenant.SignatureKey{Id: "previous", Key: oldSecret, ExpiresAt: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)},
+//	))
+func SignatureKeys(keys ...SignatureKey) Option {
+	return func(c *config) {
+		c.signatureKeys = append(c.signatureKeys, keys...)
+	}
+}
+
+// matchingSignatureKey returns the first key which isn't expired and for which the signature of the message is valid.
+func (c *config) matchingSignatureKey(message, signature []byte) (SignatureKey, bool) {
+	now := c.now()
+	for _, k := range c.signatureKeys {
+		if k.isExpired(now) {
+			continue
+		}
+		if signatureIsValid(message, signature, k.Key) {
+			return k, true
+		}
+	}
+	return SignatureKey{}, false
+}
+
+// Sign computes the signature for the tenant headers x-dv-baseuri and x-dv-tenant-id with the given
+// signatureSecretKey and returns it base64 encoded as expected in the x-dv-sig-1 header.
+//
+// It can be used to emulate the d.velop cloud, e.g. in tests or local gateways, or to forward requests
+// between services which share the same signature secret.
+func Sign(systemBaseUri string, tenantId string, signatureSecretKey []byte) string {
+	return base64.StdEncoding.EncodeToString(mac([]byte(systemBaseUri+tenantId), signatureSecretKey))
+}
+
+// SignRequest sets the tenant headers x-dv-baseuri, x-dv-tenant-id and the corresponding signature
+// header x-dv-sig-1 on the request. Headers with empty values are removed.
+//
+// Example:
+//	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+//	tenant.SignRequest(req, "https://tenant.example.com", "a12be5", signatureSecretKey)
+func SignRequest(req *http.Request, systemBaseUri string, tenantId string, signatureSecretKey []byte) {
+	setOrDelete(req.Header, systemBaseUriHeader, systemBaseUri)
+	setOrDelete(req.Header, tenantIdHeader, tenantId)
+	req.Header.Set(signatureHeader, Sign(systemBaseUri, tenantId, signatureSecretKey))
+}
+
+func setOrDelete(h http.Header, key string, value string) {
+	if value == "" {
+		h.Del(key)
+	} else {
+		h.Set(key, value)
+	}
+}
+
+func signatureIsValid(message, signature, key []byte) bool {
+	return hmac.Equal(signature, mac(message, key))
+}
+
+func mac(message, key []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write(message)
+	return m.Sum(nil)
+}
@@ -0,0 +1,118 @@
+package tenant_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+)
+
+var previousSignatureKey = []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32}
+
+func TestSignatureKeys(t *testing.T) {
+	testcases := map[string]struct {
+		signedWith     []byte
+		signatureKey   []byte
+		options        []tenant.Option
+		wantStatusCode int
+		wantKeyId      string
+	}{
+		// read function name and testCase name as one sentence. e.g. TestSignatureKeysSignedWithCurrentKey_UsesCurrentKey
+		"SignedWithCurrentKey_UsesCurrentKey": {
+			signedWith: signatureKey, signatureKey: signatureKey,
+			options:        []tenant.Option{tenant.SignatureKeys(tenant.SignatureKey{Id: "previous", Key: previousSignatureKey})},
+			wantStatusCode: http.StatusOK, wantKeyId: tenant.CurrentSignatureKeyId},
+		"SignedWithPreviousKey_UsesPreviousKey": {
+			signedWith: previousSignatureKey, signatureKey: signatureKey,
+			options:        []tenant.Option{tenant.SignatureKeys(tenant.SignatureKey{Id: "previous", Key: previousSignatureKey})},
+			wantStatusCode: http.StatusOK, wantKeyId: "previous"},
+		"SignedWithPreviousKeyWhichIsNotExpired_UsesPreviousKey": {
+			signedWith: previousSignatureKey, signatureKey: signatureKey,
+			options:        []tenant.Option{tenant.SignatureKeys(tenant.SignatureKey{Id: "previous", Key: previousSignatureKey, ExpiresAt: time.Now().Add(time.Hour)})},
+			wantStatusCode: http.StatusOK, wantKeyId: "previous"},
+		"SignedWithPreviousKeyWhichIsExpired_Returns403": {
+			signedWith: previousSignatureKey, signatureKey: signatureKey,
+			options:        []tenant.Option{tenant.SignatureKeys(tenant.SignatureKey{Id: "previous", Key: previousSignatureKey, ExpiresAt: time.Now().Add(-time.Second)})},
+			wantStatusCode: http.StatusForbidden},
+		"SignedWithKeyFromOptionAndNoSignatureSecretKey_UsesKeyFromOption": {
+			signedWith: previousSignatureKey, signatureKey: nil,
+			options:        []tenant.Option{tenant.SignatureKeys(tenant.SignatureKey{Id: "2021-05", Key: previousSignatureKey})},
+			wantStatusCode: http.StatusOK, wantKeyId: "2021-05"},
+		"SignedWithPreviousKeyButOnlyCurrentKeyConfigured_Returns403": {
+			signedWith: previousSignatureKey, signatureKey: signatureKey,
+			wantStatusCode: http.StatusForbidden},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/myresource/sub", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			const systemBaseUriFromHeader = "https://sample.example.com"
+			const tenantIdFromHeader = "a12be5"
+			req.Header.Set(systemBaseUriHeader, systemBaseUriFromHeader)
+			req.Header.Set(tenantIdHeader, tenantIdFromHeader)
+			req.Header.Set(signatureHeader, base64Signature(systemBaseUriFromHeader+tenantIdFromHeader, tc.signedWith))
+			handlerSpy := handlerSpy{}
+			responseSpy := responseSpy{httptest.NewRecorder()}
+
+			tenant.AddToCtx("", tc.signatureKey, tc.options...)(&handlerSpy).ServeHTTP(responseSpy, req)
+
+			if err := responseSpy.assertStatusCodeIs(tc.wantStatusCode); err != nil {
+				t.Error(err)
+			}
+			if tc.wantStatusCode == http.StatusOK {
+				if err := handlerSpy.assertSignatureKeyIdIs(tc.wantKeyId); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
+
+func TestNoTenantHeaders_SignatureKeyIdFromCtx_ReturnsError(t *testing.T) {
+	req, err := http.NewRequest("GET", "/myresource/sub", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlerSpy := handlerSpy{}
+
+	tenant.AddToCtx(defaultSystemBaseUri, signatureKey)(&handlerSpy).ServeHTTP(httptest.NewRecorder(), req)
+
+	if handlerSpy.errorReadingSignatureKeyId == nil {
+		t.Error("expected error while reading signatureKeyId from context")
+	}
+}
+
+func TestSystemBaseUriAndTenantId_Sign_ReturnsBase64Signature(t *testing.T) {
+	const systemBaseUri = "https://sample.example.com"
+	const tenantId = "a12be5"
+
+	got := tenant.Sign(systemBaseUri, tenantId, signatureKey)
+
+	if want := base64Signature(systemBaseUri+tenantId, signatureKey); got != want {
+		t.Errorf("wrong signature: got %v want %v", got, want)
+	}
+}
+
+func TestSignRequest_AddToCtx_AcceptsSignedHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+	tenant.SignRequest(req, "https://sample.example.com", "a12be5", signatureKey)
+	handlerSpy := handlerSpy{}
+	responseSpy := responseSpy{httptest.NewRecorder()}
+
+	tenant.AddToCtx("", signatureKey)(&handlerSpy).ServeHTTP(responseSpy, req)
+
+	if err := responseSpy.assertStatusCodeIs(http.StatusOK); err != nil {
+		t.Error(err)
+	}
+	if err := handlerSpy.assertTenantIdIs("a12be5"); err != nil {
+		t.Error(err)
+	}
+	if err := handlerSpy.assertBaseUriIs("https://sample.example.com"); err != nil {
+		t.Error(err)
+	}
+}
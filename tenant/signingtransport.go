@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"net/http"
+	"strings"
+)
+
+type signingTransport struct {
+	signatureSecretKey []byte
+	shouldSign         func(req *http.Request) bool
+	next               http.RoundTripper
+}
+
+// SigningTransport returns a http.RoundTripper which signs outbound requests for the tenant from the request context.
+//
+// The tenant headers x-dv-baseuri and x-dv-tenant-id are taken from SystemBaseUriFromCtx and IdFromCtx
+// and signed with the given signatureSecretKey. Only requests for which shouldSign returns true are signed,
+// e.g. ToHosts("*.d-velop.cloud"), because the signature is valid for any request of the tenant and must not
+// be disclosed to third parties. SigningTransport panics if shouldSign is nil.
+//
+// Requests without tenant information on the context, requests for the default tenant "0", which AddToCtx
+// uses if the request didn't contain tenant headers, and requests for a tenant which has been selected in
+// DevelopmentMode are passed on unchanged. If next is nil http.DefaultTransport is used.
+//
+// Example:
+//	client := &http.Client{
+//		Transport: tenant.SigningTransport(signatureSecretKey, tenant.ToHosts("otherservice.example.com"), nil),
+//	}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://otherservice.example.com/resource", nil)
+//	resp, err := client.Do(req)
+func SigningTransport(signatureSecretKey []byte, shouldSign func(req *http.Request) bool, next http.RoundTripper) http.RoundTripper {
+	if shouldSign == nil {
+		panic("tenant: SigningTransport requires a function which decides which requests are signed")
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &signingTransport{signatureSecretKey: signatureSecretKey, shouldSign: shouldSign, next: next}
+}
+
+// ToHosts returns a function for SigningTransport which accepts requests to the given hosts. A pattern is either
+// a host like "example.com", which has to match exactly, or a suffix rule like "*.example.com", which matches all
+// subdomains of example.com.
+func ToHosts(patterns ...string) func(req *http.Request) bool {
+	var lowercasePatterns []string
+	for _, p := range patterns {
+		lowercasePatterns = append(lowercasePatterns, strings.ToLower(p))
+	}
+	return func(req *http.Request) bool {
+		host := strings.ToLower(req.URL.Hostname())
+		return host != "" && matchesHost(lowercasePatterns, host)
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	systemBaseUri, sErr := SystemBaseUriFromCtx(ctx)
+	tenantId, tErr := IdFromCtx(ctx)
+	if sErr != nil && tErr != nil {
+		return t.next.RoundTrip(req)
+	}
+	if _, err := SignatureKeyIdFromCtx(ctx); tenantId == "0" && err != nil {
+		return t.next.RoundTrip(req)
+	}
+	if isDevelopmentTenant(ctx) {
+		return t.next.RoundTrip(req)
+	}
+	if !t.shouldSign(req) {
+		return t.next.RoundTrip(req)
+	}
+	// a RoundTripper must not modify the original request cf. https://golang.org/pkg/net/http/#RoundTripper
+	signedReq := req.Clone(ctx)
+	SignRequest(signedReq, systemBaseUri, tenantId, t.signatureSecretKey)
+	return t.next.RoundTrip(signedReq)
+}
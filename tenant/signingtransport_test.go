@@ -0,0 +1,140 @@
+package tenant_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+)
+
+func TestTenantOnContext_SigningTransport_SignsRequest(t *testing.T) {
+	handlerSpy := handlerSpy{}
+	responseSpy := responseSpy{httptest.NewRecorder()}
+	server := httptest.NewServer(tenant.AddToCtx("", signatureKey)(&handlerSpy))
+	defer server.Close()
+	ctx := tenant.SetId(context.Background(), "a12be5")
+	ctx = tenant.SetSystemBaseUri(ctx, "https://sample.example.com")
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/myresource/sub", nil)
+	req = req.WithContext(ctx)
+	client := &http.Client{Transport: tenant.SigningTransport(signatureKey, tenant.ToHosts("127.0.0.1"), nil)}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	responseSpy.Code = resp.StatusCode
+	if err := responseSpy.assertStatusCodeIs(http.StatusOK); err != nil {
+		t.Error(err)
+	}
+	if err := handlerSpy.assertTenantIdIs("a12be5"); err != nil {
+		t.Error(err)
+	}
+	if err := handlerSpy.assertBaseUriIs("https://sample.example.com"); err != nil {
+		t.Error(err)
+	}
+	if req.Header.Get(signatureHeader) != "" {
+		t.Error("original request must not be modified")
+	}
+}
+
+func TestNoTenantOnContext_SigningTransport_DoesntAddHeaders(t *testing.T) {
+	header := sendWithSigningTransport(t, context.Background(), tenant.ToHosts("127.0.0.1"))
+
+	assertNoTenantHeaders(t, header)
+}
+
+func TestHostNotAccepted_SigningTransport_DoesntAddHeaders(t *testing.T) {
+	ctx := tenant.SetId(context.Background(), "a12be5")
+	ctx = tenant.SetSystemBaseUri(ctx, "https://sample.example.com")
+
+	header := sendWithSigningTransport(t, ctx, tenant.ToHosts("*.example.com", "otherservice.example.com"))
+
+	assertNoTenantHeaders(t, header)
+}
+
+func TestDefaultTenantFromRequestWithoutTenantHeaders_SigningTransport_DoesntAddHeaders(t *testing.T) {
+	var ctx context.Context
+	req := httptest.NewRequest(http.MethodGet, "/myresource", nil)
+	tenant.AddToCtx("https://sample.example.com", signatureKey)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	header := sendWithSigningTransport(t, ctx, tenant.ToHosts("127.0.0.1"))
+
+	assertNoTenantHeaders(t, header)
+}
+
+func TestDevelopmentTenant_SigningTransport_DoesntAddHeaders(t *testing.T) {
+	muteLog()
+	var ctx context.Context
+	req := httptest.NewRequest(http.MethodGet, "/myresource", nil)
+	req.Header.Set("x-dv-dev-tenant-id", "a12be5")
+	tenant.AddToCtx("https://sample.example.com", signatureKey, tenant.DevelopmentMode(tenant.DevelopmentTenant{Id: "a12be5"}))(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	header := sendWithSigningTransport(t, ctx, tenant.ToHosts("127.0.0.1"))
+
+	assertNoTenantHeaders(t, header)
+}
+
+func TestNilShouldSign_SigningTransport_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+
+	tenant.SigningTransport(signatureKey, nil, nil)
+}
+
+func TestToHosts(t *testing.T) {
+	testcases := map[string]bool{
+		"https://otherservice.example.com/resource":     true,
+		"https://OtherService.Example.com:8443/":        true,
+		"https://sub.d-velop.cloud/resource":            true,
+		"https://d-velop.cloud.evil.example/resource":   false,
+		"https://evil.example/otherservice.example.com": false,
+	}
+
+	for u, want := range testcases {
+		t.Run(u, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, u, nil)
+			if got := tenant.ToHosts("otherservice.example.com", "*.d-velop.cloud")(req); got != want {
+				t.Errorf("wrong result: got %v want %v", got, want)
+			}
+		})
+	}
+}
+
+func sendWithSigningTransport(t *testing.T, ctx context.Context, shouldSign func(req *http.Request) bool) http.Header {
+	t.Helper()
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	client := &http.Client{Transport: tenant.SigningTransport(signatureKey, shouldSign, nil)}
+
+	resp, err := client.Do(req.WithContext(ctx))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	return header
+}
+
+func assertNoTenantHeaders(t *testing.T, header http.Header) {
+	t.Helper()
+	for _, h := range []string{systemBaseUriHeader, tenantIdHeader, signatureHeader} {
+		if header.Get(h) != "" {
+			t.Errorf("unexpected header %v: %v", h, header.Get(h))
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/d-velop/dvelop-sdk-go/otellog"
+)
+
+// State is the lifecycle state of a tenant.
+type State string
+
+const (
+	// StateUnknown is the state of a tenant which isn't known to the registry, e.g. because it never booked the App.
+	StateUnknown State = ""
+	// StateActive is the state of a tenant which booked the App.
+	StateActive State = "active"
+	// StateSuspended is the state of a tenant which cancelled the App but whose data hasn't been deleted yet.
+	StateSuspended State = "suspended"
+	// StatePurged is the state of a tenant whose data has been deleted.
+	StatePurged State = "purged"
+)
+
+const defaultStateCacheTtl = time.Minute
+
+// Registry is an interface representing the ability to look up the lifecycle state of a tenant.
+type Registry interface {
+	// State returns the state of the tenant or StateUnknown if the tenant isn't known.
+	//
+	// An error is returned if something unexpected occurred.
+	State(ctx context.Context, tenantId string) (State, error)
+}
+
+type lifecycleEventStoreRegistry struct {
+	store LifecycleEventStore
+}
+
+// RegistryFromLifecycleEventStore returns a Registry which derives the state of a tenant from the last lifecycle
+// event which has been processed by LifecycleEventHandler, cf. WithLifecycleEventStore.
+func RegistryFromLifecycleEventStore(store LifecycleEventStore) Registry {
+	return &lifecycleEventStoreRegistry{store: store}
+}
+
+func (r *lifecycleEventStoreRegistry) State(ctx context.Context, tenantId string) (State, error) {
+	eventType, err := r.store.LastProcessed(ctx, tenantId)
+	if err != nil {
+		return StateUnknown, err
+	}
+	switch eventType {
+	case Subscribe, Resubscribe:
+		return StateActive, nil
+	case Unsubscribe:
+		return StateSuspended, nil
+	case Purge:
+		return StatePurged, nil
+	}
+	return StateUnknown, nil
+}
+
+// An InactiveTenantHandlerFunc writes the response for a request of a tenant which isn't active.
+type InactiveTenantHandlerFunc func(rw http.ResponseWriter, req *http.Request, state State)
+
+// A StateOption configures the behaviour of RequireActive.
+type StateOption func(*stateEnforcer)
+
+// InactiveTenantHandler sets the function which writes the response for requests of tenants which aren't active.
+//
+// The default answers with status 410 for purged tenants and with status 403 otherwise.
+func InactiveTenantHandler(f InactiveTenantHandlerFunc) StateOption {
+	return func(e *stateEnforcer) {
+		e.inactiveTenantHandler = f
+	}
+}
+
+// StateCacheTtl sets the duration for which the state of a tenant is cached. Default is one minute.
+// A duration <= 0 disables the cache.
+func StateCacheTtl(ttl time.Duration) StateOption {
+	return func(e *stateEnforcer) {
+		e.ttl = ttl
+	}
+}
+
+// UnknownTenantsActive treats tenants which are unknown to the registry (StateUnknown) as active, e.g. because the
+// registry is filled by lifecycle events and tenants which booked the App before haven't been migrated yet.
+// By default unknown tenants are rejected.
+func UnknownTenantsActive() StateOption {
+	return func(e *stateEnforcer) {
+		e.unknownIsActive = true
+	}
+}
+
+// DefaultInactiveTenantHandler answers with status 410 for purged tenants and with status 403 otherwise.
+func DefaultInactiveTenantHandler(rw http.ResponseWriter, _ *http.Request, state State) {
+	status := http.StatusForbidden
+	if state == StatePurged {
+		status = http.StatusGone
+	}
+	http.Error(rw, http.StatusText(status), status)
+}
+
+type cachedState struct {
+	state     State
+	expiresAt time.Time
+}
+
+type stateEnforcer struct {
+	registry              Registry
+	inactiveTenantHandler InactiveTenantHandlerFunc
+	unknownIsActive       bool
+	ttl                   time.Duration
+	now                   func() time.Time
+
+	mu        sync.Mutex
+	cache     map[string]cachedState
+	lastPurge time.Time
+}
+
+// RequireActive rejects requests of tenants which aren't active according to the registry, e.g. because they
+// cancelled the App but requests are still routed to it for a while.
+//
+// The tenant is determined by IdFromCtx, so RequireActive must be used after AddToCtx. Requests without a tenant
+// on the context are rejected as well. The states are cached (cf. StateCacheTtl) and the response for inactive
+// tenants can be configured by InactiveTenantHandler. Tenants which are unknown to the registry are treated as
+// inactive unless UnknownTenantsActive is used. If the registry fails the request is answered with status 500.
+//
+// Example:
+//	func main() {
+//		store := tenant.NewInMemoryLifecycleEventStore()
+//		mux := http.NewServeMux()
+//		mux.Handle("/myapp/dvelop-cloud-lifecycle-event", tenant.LifecycleEventHandler(appSecret, callbacks, tenant.WithLifecycleEventStore(store)))
+//		requireActive := tenant.RequireActive(tenant.RegistryFromLifecycleEventStore(store))
+//		mux.Handle("/myapp/hello", tenant.AddToCtx(os.Getenv("systemBaseUri"), signatureSecretKey)(requireActive(helloHandler())))
+//	}
+func RequireActive(registry Registry, options ...StateOption) func(http.Handler) http.Handler {
+	e := &stateEnforcer{
+		registry:              registry,
+		inactiveTenantHandler: DefaultInactiveTenantHandler,
+		ttl:                   defaultStateCacheTtl,
+		now:                   time.Now,
+		cache:                 map[string]cachedState{},
+	}
+	for _, o := range options {
+		o(e)
+	}
+	e.lastPurge = e.now()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			tenantId, err := IdFromCtx(ctx)
+			if err != nil {
+				e.inactiveTenantHandler(rw, req, StateUnknown)
+				return
+			}
+			state, err := e.state(ctx, tenantId)
+			if err != nil {
+				log.WithName("TenantStateLookupFailed").
+					With(func(ev *log.Event) {
+						ev.TenantId = tenantId
+					}).
+					Errorf(ctx, "error reading state of tenant because: %v", err)
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if state != StateActive && !(state == StateUnknown && e.unknownIsActive) {
+				e.inactiveTenantHandler(rw, req, state)
+				return
+			}
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+func (e *stateEnforcer) state(ctx context.Context, tenantId string) (State, error) {
+	if e.ttl <= 0 {
+		return e.registry.State(ctx, tenantId)
+	}
+	e.mu.Lock()
+	cached, ok := e.cache[tenantId]
+	if ok && !e.now().Before(cached.expiresAt) {
+		delete(e.cache, tenantId)
+		ok = false
+	}
+	e.mu.Unlock()
+	if ok {
+		return cached.state, nil
+	}
+
+	state, err := e.registry.State(ctx, tenantId)
+	if err != nil {
+		return StateUnknown, err
+	}
+	e.mu.Lock()
+	now := e.now()
+	e.purgeExpired(now)
+	e.cache[tenantId] = cachedState{state: state, expiresAt: now.Add(e.ttl)}
+	e.mu.Unlock()
+	return state, nil
+}
+
+// purgeExpired discards the expired states at most once per ttl, so the cache doesn't grow with tenants which
+// aren't active anymore. It must be called with e.mu held.
+func (e *stateEnforcer) purgeExpired(now time.Time) {
+	if now.Sub(e.lastPurge) < e.ttl {
+		return
+	}
+	for tenantId, cached := range e.cache {
+		if !now.Before(cached.expiresAt) {
+			delete(e.cache, tenantId)
+		}
+	}
+	e.lastPurge = now
+}
@@ -0,0 +1,70 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequireActive_CachesStatePerTenantUntilTtlExpires(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	withClock := func(e *stateEnforcer) {
+		e.now = clock.Now
+	}
+	registry := &countingRegistry{states: map[string]State{"a12be5": StateActive, "ff00e1": StateActive}}
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	handler := RequireActive(registry, StateCacheTtl(time.Minute), withClock)(next)
+	serve := func(tenantId string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(SetId(context.Background(), tenantId)))
+		return rec.Code
+	}
+
+	serve("a12be5")
+	serve("a12be5")
+	serve("ff00e1")
+	if registry.calls != 2 {
+		t.Errorf("wrong number of lookups: got %v want %v", registry.calls, 2)
+	}
+
+	registry.states["a12be5"] = StateSuspended
+	clock.Advance(time.Minute)
+	if got := serve("a12be5"); got != http.StatusForbidden {
+		t.Errorf("state should have been looked up again: got %v want %v", got, http.StatusForbidden)
+	}
+}
+
+func TestExpiredStates_RequireActive_DiscardsStatesOnWrite(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	var enforcer *stateEnforcer
+	withClock := func(e *stateEnforcer) {
+		e.now = clock.Now
+		enforcer = e
+	}
+	registry := &countingRegistry{states: map[string]State{}}
+	handler := RequireActive(registry, StateCacheTtl(time.Minute), withClock)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	serve := func(tenantId string) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(SetId(context.Background(), tenantId)))
+	}
+	serve("a12be5")
+	serve("ff00e1")
+
+	clock.Advance(time.Minute)
+	serve("c0ffee")
+
+	if _, ok := enforcer.cache["a12be5"]; ok || len(enforcer.cache) != 1 {
+		t.Errorf("expired states should have been discarded: got %v", enforcer.cache)
+	}
+}
+
+type countingRegistry struct {
+	states map[string]State
+	calls  int
+}
+
+func (r *countingRegistry) State(_ context.Context, tenantId string) (State, error) {
+	r.calls++
+	return r.states[tenantId], nil
+}
@@ -0,0 +1,165 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/d-velop/dvelop-sdk-go/tenant"
+)
+
+func TestTenantState_RequireActive(t *testing.T) {
+	testcases := []struct {
+		state      tenant.State
+		wantStatus int
+		wantCalled bool
+	}{
+		{tenant.StateActive, http.StatusOK, true},
+		{tenant.StateSuspended, http.StatusForbidden, false},
+		{tenant.StatePurged, http.StatusGone, false},
+		{tenant.StateUnknown, http.StatusForbidden, false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(string(tc.state), func(t *testing.T) {
+			handlerSpy := &handlerSpy{}
+			registry := &registrySpy{states: map[string]tenant.State{"a12be5": tc.state}}
+
+			rec := serveForTenant(tenant.RequireActive(registry)(handlerSpy), "a12be5")
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("wrong status code: got %v want %v", rec.Code, tc.wantStatus)
+			}
+			if handlerSpy.hasBeenCalled != tc.wantCalled {
+				t.Errorf("inner handler called: got %v want %v", handlerSpy.hasBeenCalled, tc.wantCalled)
+			}
+		})
+	}
+}
+
+func TestNoTenantOnCtx_RequireActive_RejectsRequest(t *testing.T) {
+	handlerSpy := &handlerSpy{}
+	rec := httptest.NewRecorder()
+
+	tenant.RequireActive(&registrySpy{})(handlerSpy).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusForbidden || handlerSpy.hasBeenCalled {
+		t.Errorf("request should have been rejected: got %v", rec.Code)
+	}
+}
+
+func TestRegistryFails_RequireActive_Returns500(t *testing.T) {
+	muteLog()
+	registry := &registrySpy{err: errors.New("registry not available")}
+
+	rec := serveForTenant(tenant.RequireActive(registry)(&handlerSpy{}), "a12be5")
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestInactiveTenantHandler_RequireActive_UsesHandler(t *testing.T) {
+	var got tenant.State
+	inactiveTenantHandler := func(rw http.ResponseWriter, req *http.Request, state tenant.State) {
+		got = state
+		rw.WriteHeader(http.StatusNotFound)
+	}
+	registry := &registrySpy{states: map[string]tenant.State{"a12be5": tenant.StateSuspended}}
+
+	rec := serveForTenant(tenant.RequireActive(registry, tenant.InactiveTenantHandler(inactiveTenantHandler))(&handlerSpy{}), "a12be5")
+
+	if rec.Code != http.StatusNotFound || got != tenant.StateSuspended {
+		t.Errorf("wrong response: got %v and state %v", rec.Code, got)
+	}
+}
+
+func TestUnknownTenantsActive_RequireActive(t *testing.T) {
+	testcases := []struct {
+		state      tenant.State
+		wantStatus int
+	}{
+		{tenant.StateUnknown, http.StatusOK},
+		{tenant.StateActive, http.StatusOK},
+		{tenant.StateSuspended, http.StatusForbidden},
+		{tenant.StatePurged, http.StatusGone},
+	}
+
+	for _, tc := range testcases {
+		t.Run(string(tc.state), func(t *testing.T) {
+			registry := &registrySpy{states: map[string]tenant.State{"a12be5": tc.state}}
+
+			rec := serveForTenant(tenant.RequireActive(registry, tenant.UnknownTenantsActive())(&handlerSpy{}), "a12be5")
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("wrong status code: got %v want %v", rec.Code, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCacheDisabled_RequireActive_LooksUpStateForEachRequest(t *testing.T) {
+	registry := &registrySpy{states: map[string]tenant.State{"a12be5": tenant.StateActive}}
+	handler := tenant.RequireActive(registry, tenant.StateCacheTtl(0))(&handlerSpy{})
+
+	serveForTenant(handler, "a12be5")
+	serveForTenant(handler, "a12be5")
+
+	if registry.calls != 2 {
+		t.Errorf("wrong number of lookups: got %v want %v", registry.calls, 2)
+	}
+}
+
+func TestLifecycleEvents_RegistryFromLifecycleEventStore_ReturnsState(t *testing.T) {
+	store := tenant.NewInMemoryLifecycleEventStore()
+	registry := tenant.RegistryFromLifecycleEventStore(store)
+	testcases := []struct {
+		event tenant.LifecycleEventType
+		want  tenant.State
+	}{
+		{"", tenant.StateUnknown},
+		{tenant.Subscribe, tenant.StateActive},
+		{tenant.Unsubscribe, tenant.StateSuspended},
+		{tenant.Resubscribe, tenant.StateActive},
+		{tenant.Unsubscribe, tenant.StateSuspended},
+		{tenant.Purge, tenant.StatePurged},
+	}
+
+	var last tenant.LifecycleEventType
+	for _, tc := range testcases {
+		store.CompareAndSetProcessed(context.Background(), "a12be5", last, tc.event)
+		last = tc.event
+
+		if got, err := registry.State(context.Background(), "a12be5"); err != nil || got != tc.want {
+			t.Errorf("wrong state after %v: got %v, %v want %v", tc.event, got, err, tc.want)
+		}
+	}
+}
+
+func TestResubscribedTenant_RequireActive_CallsNextHandler(t *testing.T) {
+	store := tenant.NewInMemoryLifecycleEventStore()
+	lifecycle := tenant.LifecycleEventHandler(appSecret, tenant.LifecycleCallbacks{}, tenant.WithLifecycleEventStore(store))
+	handler := tenant.RequireActive(tenant.RegistryFromLifecycleEventStore(store), tenant.StateCacheTtl(0))(&handlerSpy{})
+
+	for _, event := range []string{"subscribe", "unsubscribe", "resubscribe"} {
+		lifecycle.ServeHTTP(httptest.NewRecorder(), lifecycleRequest(`{"type":"`+event+`","tenantId":"a12be5"}`, appSecret, time.Now()))
+	}
+
+	if rec := serveForTenant(handler, "a12be5"); rec.Code != http.StatusOK {
+		t.Errorf("wrong status code: got %v want %v", rec.Code, http.StatusOK)
+	}
+}
+
+type registrySpy struct {
+	states map[string]tenant.State
+	err    error
+	calls  int
+}
+
+func (r *registrySpy) State(_ context.Context, tenantId string) (tenant.State, error) {
+	r.calls++
+	return r.states[tenantId], r.err
+}
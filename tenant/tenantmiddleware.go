@@ -33,13 +33,13 @@ package tenant
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/base64"
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
 	"strings"
+
+	"github.com/d-velop/dvelop-sdk-go/tenant/forwarded"
 )
 
 type contextKey string
@@ -48,20 +48,38 @@ const (
 	systemBaseUriCtxKey          = contextKey("systemBaseUri")
 	tenantIdCtxKey               = contextKey("tenantId")
 	initiatorSystemBaseUriCtxKey = contextKey("sourceSystemBaseUri")
+	signatureKeyIdCtxKey         = contextKey("signatureKeyId")
+	developmentTenantCtxKey      = contextKey("developmentTenant")
 	systemBaseUriHeader          = "x-dv-baseuri"
 	tenantIdHeader               = "x-dv-tenant-id"
-	forwardedHeader              = "forwarded"
-	xForwardedHostHeader         = "x-forwarded-host"
-	commaDelimiter               = ","
-	colonDelimiter               = ";"
-	forwardedHostPattern         = "host="
-	uriPrefix                    = "https://"
+	signatureHeader              = "x-dv-sig-1"
+	forwardedHeader              = "Forwarded"
 )
 
 // Adds systemBaseUri and tenantId to request context.
 // If the headers are not present the given defaultSystemBaseUri and tenant "0" are used.
 // The signatureSecretKey is specific for each App and is provided by the registration process for d.velop cloud.
-func AddToCtx(defaultSystemBaseUri string, signatureSecretKey []byte) func(http.Handler) http.Handler {
+// Further behaviour can be configured by options like SignatureKeys, ErrorHandler, Logger, TrustedProxies or DevelopmentMode.
+//
+// The InitiatorSystemBaseUri is derived from the forwarding headers selected by TrustedProxyHeaders only if the request
+// has been sent by a trusted proxy (cf. TrustedProxies). Otherwise the signed x-dv-baseuri is used. Only the last forwarded element is evaluated, which
+// has been added by the trusted proxy, because the preceding elements might have been sent by the client. The forwarded
+// base uri must be valid like the systemBaseUri, except that http is accepted as well, and is ignored otherwise.
+//
+// The systemBaseUri must be an absolute https URL without path, query and userinfo and is normalized to the form
+// https://host[:port]. For loopback hosts like http://localhost:8080 http is accepted as well. Its host can be restricted by AllowedHosts and the format of the tenant id by TenantIdPattern.
+// Requests with invalid values are rejected with status 400. AddToCtx panics if the defaultSystemBaseUri is invalid.
+func AddToCtx(defaultSystemBaseUri string, signatureSecretKey []byte, options ...Option) func(http.Handler) http.Handler {
+	c := newConfig(signatureSecretKey, options)
+	if defaultSystemBaseUri != "" {
+		normalized, err := c.normalizeSystemBaseUri(defaultSystemBaseUri)
+		if err != nil {
+			panic(fmt.Sprintf("tenant: invalid defaultSystemBaseUri: %v", err))
+		}
+		defaultSystemBaseUri = normalized
+	}
+	c.checkTrustedProxies()
+	c.checkDevelopmentMode()
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 			ctx := req.Context()
@@ -70,23 +88,44 @@ func AddToCtx(defaultSystemBaseUri string, signatureSecretKey []byte) func(http.
 			tenantId := req.Header.Get(tenantIdHeader)
 
 			if systemBaseUri != "" || tenantId != "" {
-				if signatureSecretKey == nil {
-					log.Printf("error validating signature for headers '%v' and '%v' because secret signature key has not been configured", systemBaseUriHeader, tenantIdHeader)
-					http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				if len(c.signatureKeys) == 0 {
+					c.fail(rw, req, &VerificationError{TenantId: tenantId, SystemBaseUri: systemBaseUri, StatusCode: http.StatusInternalServerError, Reason: ErrSignatureKeyMissing})
 					return
 				}
-				base64Signature := req.Header.Get("x-dv-sig-1")
-				signature, err := base64.StdEncoding.DecodeString(base64Signature)
+				signature, err := base64.StdEncoding.DecodeString(req.Header.Get(signatureHeader))
 				if err != nil {
-					log.Printf("error decoding signature '%v' as base 64 data because: %v", base64Signature, err)
-					http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					c.fail(rw, req, &VerificationError{TenantId: tenantId, SystemBaseUri: systemBaseUri, StatusCode: http.StatusForbidden, Reason: ErrSignatureMalformed})
 					return
 				}
-				if !signatureIsValid([]byte(systemBaseUri+tenantId), []byte(signature), signatureSecretKey) {
-					log.Printf("error signature '%v' is not valid for SystemBaseUri '%v' and TenantId '%v'", signature, systemBaseUri, tenantId)
-					http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				key, valid := c.matchingSignatureKey([]byte(systemBaseUri+tenantId), signature)
+				if !valid {
+					c.fail(rw, req, &VerificationError{TenantId: tenantId, SystemBaseUri: systemBaseUri, StatusCode: http.StatusForbidden, Reason: ErrSignatureInvalid})
 					return
 				}
+				ctx = context.WithValue(ctx, signatureKeyIdCtxKey, key.Id)
+
+				if systemBaseUri != "" {
+					normalized, err := c.normalizeSystemBaseUri(systemBaseUri)
+					if err != nil {
+						c.fail(rw, req, &VerificationError{TenantId: tenantId, SystemBaseUri: systemBaseUri, StatusCode: http.StatusBadRequest, Reason: err})
+						return
+					}
+					systemBaseUri = normalized
+				}
+				if tenantId != "" {
+					if err := c.validateTenantId(tenantId); err != nil {
+						c.fail(rw, req, &VerificationError{TenantId: tenantId, SystemBaseUri: systemBaseUri, StatusCode: http.StatusBadRequest, Reason: err})
+						return
+					}
+				}
+			} else if selected := c.selectedDevelopmentTenant(req); selected != "" {
+				t, allowed := c.developmentTenants[selected]
+				if !allowed {
+					c.fail(rw, req, &VerificationError{TenantId: selected, StatusCode: http.StatusForbidden, Reason: ErrDevelopmentTenantNotAllowed})
+					return
+				}
+				tenantId, systemBaseUri = t.Id, t.SystemBaseUri
+				ctx = context.WithValue(ctx, developmentTenantCtxKey, true)
 			}
 
 			if tenantId == "" {
@@ -100,6 +139,7 @@ func AddToCtx(defaultSystemBaseUri string, signatureSecretKey []byte) func(http.
 				ctx = context.WithValue(ctx, tenantIdCtxKey, tenantId)
 			}
 
+			signedSystemBaseUri := systemBaseUri
 			if systemBaseUri == "" {
 				systemBaseUri = defaultSystemBaseUri
 			}
@@ -107,7 +147,7 @@ func AddToCtx(defaultSystemBaseUri string, signatureSecretKey []byte) func(http.
 				ctx = context.WithValue(ctx, systemBaseUriCtxKey, systemBaseUri)
 			}
 
-			initiatorSystemBaseUri := getInitiatorSystemBaseUri(req)
+			initiatorSystemBaseUri := c.getInitiatorSystemBaseUri(req, signedSystemBaseUri)
 			if initiatorSystemBaseUri == "" {
 				initiatorSystemBaseUri = defaultSystemBaseUri
 			}
@@ -119,53 +159,28 @@ func AddToCtx(defaultSystemBaseUri string, signatureSecretKey []byte) func(http.
 	}
 }
 
-func signatureIsValid(message, signature, key []byte) bool {
-	mac := hmac.New(sha256.New, key)
-	mac.Write(message)
-	expectedMAC := mac.Sum(nil)
-	return hmac.Equal(signature, expectedMAC)
-}
-
 // returns the initial host which initiates current request
 // it is essential in hybrid systems
-func getInitiatorSystemBaseUri(req *http.Request) string {
-	var initiatorSystemBaseUri string
-	forwardedHeaderValue := req.Header.Get(forwardedHeader)
-	xForwardedHostHeaderValue := req.Header.Get(xForwardedHostHeader)
-	systemBaseUri := req.Header.Get(systemBaseUriHeader)
-
-	initiatorSystemBaseUri = getForwardedHeaderFirstHostValueAsUri(forwardedHeaderValue)
-	if initiatorSystemBaseUri == "" {
-		host := getFirstValueOfDelimitedList(xForwardedHostHeaderValue, commaDelimiter)
-		if host != "" {
-			initiatorSystemBaseUri = uriPrefix + host
-		} else {
-			initiatorSystemBaseUri = systemBaseUri
-		}
+// the forwarding headers are only evaluated if the request has been sent by a trusted proxy
+// and are ignored if they don't contain a valid base uri of an allowed host (cf. AllowedHosts)
+func (c *config) getInitiatorSystemBaseUri(req *http.Request, signedSystemBaseUri string) string {
+	if !c.isTrustedProxy(req) {
+		return signedSystemBaseUri
 	}
-	return initiatorSystemBaseUri
-}
-
-func getForwardedHeaderFirstHostValueAsUri(headerValue string) string {
-	if headerValue != "" {
-		for _, value := range strings.Split(headerValue, colonDelimiter) {
-			if strings.HasPrefix(value, forwardedHostPattern) {
-				hostValue := strings.TrimPrefix(value, forwardedHostPattern)
-				host := getFirstValueOfDelimitedList(hostValue, commaDelimiter)
-				if host != "" {
-					return uriPrefix + host
-				}
-			}
-		}
+	var elements []forwarded.Element
+	switch c.proxyHeaders {
+	case ProxyHeadersForwarded:
+		elements, _ = forwarded.Parse(strings.Join(req.Header[forwardedHeader], ","))
+	case ProxyHeadersXForwarded:
+		elements = forwarded.ParseXForwarded(req.Header)
 	}
-	return ""
-}
-
-func getFirstValueOfDelimitedList(delimitedList string, delimiter string) string {
-	if delimitedList == "" {
-		return delimitedList
+	if len(elements) == 0 {
+		return signedSystemBaseUri
 	}
-	return strings.Split(delimitedList, delimiter)[0]
+	if u, err := c.normalizeInitiatorSystemBaseUri(elements[len(elements)-1].BaseUri()); err == nil {
+		return u
+	}
+	return signedSystemBaseUri
 }
 
 // SystemBaseUriFromCtx reads the systemBaseUri from the context.
@@ -195,6 +210,17 @@ func InitiatorSystemBaseUriFromCtx(ctx context.Context) (string, error) {
 	return initiatorSystemBaseUri, nil
 }
 
+// SignatureKeyIdFromCtx reads the id of the signature key which verified the tenant headers of the current request from the context.
+//
+// An error is returned if the request didn't contain tenant headers.
+func SignatureKeyIdFromCtx(ctx context.Context) (string, error) {
+	signatureKeyId, ok := ctx.Value(signatureKeyIdCtxKey).(string)
+	if !ok {
+		return "", errors.New("no SignatureKeyId on context")
+	}
+	return signatureKeyId, nil
+}
+
 // SetId returns a new context.Context with the given tenantId
 func SetId(ctx context.Context, tenantId string) context.Context {
 	return context.WithValue(ctx, tenantIdCtxKey, tenantId)
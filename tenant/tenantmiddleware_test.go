@@ -21,6 +21,8 @@ const (
 	forwardedHeader      = "forwarded"
 	xForwardedHostHeader = "x-forwarded-host"
 	uriPrefix            = "https://"
+	trustedProxyNetwork  = "10.0.0.0/8"
+	trustedProxyAddr     = "10.0.0.1:4711"
 )
 
 func TestBaseUriHeaderAndEmptyDefaultBaseUri_UsesHeader(t *testing.T) {
@@ -141,6 +143,7 @@ func TestInitiatorSystemBaseUriHeader_UsesForwardedHeader(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.RemoteAddr = trustedProxyAddr
 	const forwardedHostValue = "forwarded.example.com"
 	const forwardedHeaderValue = "host=" + forwardedHostValue
 	req.Header.Set(forwardedHeader, forwardedHeaderValue)
@@ -148,7 +151,7 @@ func TestInitiatorSystemBaseUriHeader_UsesForwardedHeader(t *testing.T) {
 	handlerSpy := handlerSpy{}
 	responseSpy := responseSpy{httptest.NewRecorder()}
 
-	tenant.AddToCtx("", signatureKey)(&handlerSpy).ServeHTTP(responseSpy, req)
+	tenant.AddToCtx("", signatureKey, tenant.TrustedProxies(trustedProxyNetwork), tenant.TrustedProxyHeaders(tenant.ProxyHeadersForwarded))(&handlerSpy).ServeHTTP(responseSpy, req)
 
 	if err := responseSpy.assertStatusCodeIs(http.StatusOK); err != nil {
 		t.Error(err)
@@ -158,19 +161,20 @@ func TestInitiatorSystemBaseUriHeader_UsesForwardedHeader(t *testing.T) {
 	}
 }
 
-func TestInitiatorSystemBaseUriHeader_UsesForwardedHeaderMultipleHosts(t *testing.T) {
+func TestInitiatorSystemBaseUriHeader_UsesLastElementOfForwardedHeader(t *testing.T) {
 	req, err := http.NewRequest("GET", "/myresource/sub", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.RemoteAddr = trustedProxyAddr
 	const forwardedHostValue = "forwarded.example.com"
-	const forwardedHeaderValue = "host=" + forwardedHostValue + ",secondhost.example.com"
+	const forwardedHeaderValue = "host=firsthost.example.com,host=" + forwardedHostValue
 	req.Header.Set(forwardedHeader, forwardedHeaderValue)
 	req.Header.Set(signatureHeader, base64Signature(forwardedHeaderValue, signatureKey))
 	handlerSpy := handlerSpy{}
 	responseSpy := responseSpy{httptest.NewRecorder()}
 
-	tenant.AddToCtx("", signatureKey)(&handlerSpy).ServeHTTP(responseSpy, req)
+	tenant.AddToCtx("", signatureKey, tenant.TrustedProxies(trustedProxyNetwork), tenant.TrustedProxyHeaders(tenant.ProxyHeadersForwarded))(&handlerSpy).ServeHTTP(responseSpy, req)
 
 	if err := responseSpy.assertStatusCodeIs(http.StatusOK); err != nil {
 		t.Error(err)
@@ -185,13 +189,14 @@ func TestInitiatorSystemBaseUriHeader_UsesXForwardedHeader(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.RemoteAddr = trustedProxyAddr
 	const xForwardedHostValue = "xforwarded.example.com"
 	req.Header.Set(xForwardedHostHeader, xForwardedHostValue)
 	req.Header.Set(signatureHeader, base64Signature(xForwardedHostValue, signatureKey))
 	handlerSpy := handlerSpy{}
 	responseSpy := responseSpy{httptest.NewRecorder()}
 
-	tenant.AddToCtx("", signatureKey)(&handlerSpy).ServeHTTP(responseSpy, req)
+	tenant.AddToCtx("", signatureKey, tenant.TrustedProxies(trustedProxyNetwork), tenant.TrustedProxyHeaders(tenant.ProxyHeadersXForwarded))(&handlerSpy).ServeHTTP(responseSpy, req)
 
 	if err := responseSpy.assertStatusCodeIs(http.StatusOK); err != nil {
 		t.Error(err)
@@ -201,19 +206,20 @@ func TestInitiatorSystemBaseUriHeader_UsesXForwardedHeader(t *testing.T) {
 	}
 }
 
-func TestInitiatorSystemBaseUriHeader_UsesXForwardedHeaderMultipleHosts(t *testing.T) {
+func TestInitiatorSystemBaseUriHeader_UsesLastElementOfXForwardedHeader(t *testing.T) {
 	req, err := http.NewRequest("GET", "/myresource/sub", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.RemoteAddr = trustedProxyAddr
 	const xForwardedHostValue = "xforwarded.example.com"
-	const xForwardedHostMultiValue = xForwardedHostValue + ",secondhost.example.com"
+	const xForwardedHostMultiValue = "firsthost.example.com," + xForwardedHostValue
 	req.Header.Set(xForwardedHostHeader, xForwardedHostMultiValue)
 	req.Header.Set(signatureHeader, base64Signature(xForwardedHostMultiValue, signatureKey))
 	handlerSpy := handlerSpy{}
 	responseSpy := responseSpy{httptest.NewRecorder()}
 
-	tenant.AddToCtx("", signatureKey)(&handlerSpy).ServeHTTP(responseSpy, req)
+	tenant.AddToCtx("", signatureKey, tenant.TrustedProxies(trustedProxyNetwork), tenant.TrustedProxyHeaders(tenant.ProxyHeadersXForwarded))(&handlerSpy).ServeHTTP(responseSpy, req)
 
 	if err := responseSpy.assertStatusCodeIs(http.StatusOK); err != nil {
 		t.Error(err)
@@ -223,6 +229,222 @@ func TestInitiatorSystemBaseUriHeader_UsesXForwardedHeaderMultipleHosts(t *testi
 	}
 }
 
+func TestInitiatorSystemBaseUriHeader_UsesSchemeOfForwardedHeaders(t *testing.T) {
+	testcases := []struct {
+		proxyHeaders tenant.ProxyHeaders
+		headers      map[string]string
+		want         string
+	}{
+		{tenant.ProxyHeadersForwarded, map[string]string{forwardedHeader: "proto=http;host=forwarded.example.com"}, "http://forwarded.example.com"},
+		{tenant.ProxyHeadersForwarded, map[string]string{forwardedHeader: `for="[2001:db8:cafe::17]:4711";host="forwarded.example.com:8443";proto=https`}, "https://forwarded.example.com:8443"},
+		{tenant.ProxyHeadersXForwarded, map[string]string{forwardedHeader: "for=192.0.2.43", xForwardedHostHeader: "xforwarded.example.com"}, "https://xforwarded.example.com"},
+		{tenant.ProxyHeadersXForwarded, map[string]string{xForwardedHostHeader: "xforwarded.example.com", "x-forwarded-proto": "http"}, "http://xforwarded.example.com"},
+		{tenant.ProxyHeadersXForwarded, map[string]string{forwardedHeader: `host="forwarded.example.com`, xForwardedHostHeader: "xforwarded.example.com"}, "https://xforwarded.example.com"},
+		{tenant.ProxyHeadersForwarded, map[string]string{forwardedHeader: `proto=HTTP;host="Forwarded.Example.com:80"`}, "http://forwarded.example.com"},
+		{tenant.ProxyHeadersForwarded, map[string]string{forwardedHeader: `host="forwarded.example.com:443"`}, "https://forwarded.example.com"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(fmt.Sprint(tc.headers), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+			req.RemoteAddr = trustedProxyAddr
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			handlerSpy := handlerSpy{}
+
+			tenant.AddToCtx("", signatureKey, tenant.TrustedProxies(trustedProxyNetwork), tenant.TrustedProxyHeaders(tc.proxyHeaders))(&handlerSpy).ServeHTTP(httptest.NewRecorder(), req)
+
+			if err := handlerSpy.assertInitiatorSystemBaseUriIs(tc.want); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestForwardedHeadersSentByClientAndAppendedByTrustedProxy_UsesElementOfTrustedProxy(t *testing.T) {
+	testcases := map[string]struct {
+		proxyHeaders  tenant.ProxyHeaders
+		fromClient    map[string]string
+		fromProxy     map[string]string
+		wantInitiator string
+	}{
+		"Forwarded": {
+			tenant.ProxyHeadersForwarded,
+			map[string]string{forwardedHeader: "host=evil.example.com;proto=http"},
+			map[string]string{forwardedHeader: "for=192.0.2.43;host=forwarded.example.com;proto=https"},
+			"https://forwarded.example.com",
+		},
+		"XForwarded": {
+			tenant.ProxyHeadersXForwarded,
+			map[string]string{xForwardedHostHeader: "evil.example.com", "x-forwarded-proto": "http", "x-forwarded-for": "198.51.100.17"},
+			map[string]string{xForwardedHostHeader: "xforwarded.example.com", "x-forwarded-proto": "https", "x-forwarded-for": "192.0.2.43"},
+			"https://xforwarded.example.com",
+		},
+		"ForwardedFromClientAndXForwardedFromProxy": {
+			tenant.ProxyHeadersXForwarded,
+			map[string]string{forwardedHeader: "host=evil.example.com"},
+			map[string]string{xForwardedHostHeader: "xforwarded.example.com"},
+			"https://xforwarded.example.com",
+		},
+		"XForwardedFromClientAndForwardedFromProxy": {
+			tenant.ProxyHeadersForwarded,
+			map[string]string{xForwardedHostHeader: "evil.example.com"},
+			map[string]string{forwardedHeader: "for=192.0.2.43"},
+			"https://sample.example.com",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			const systemBaseUri = "https://sample.example.com"
+			req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+			req.RemoteAddr = trustedProxyAddr
+			req.Header.Set(systemBaseUriHeader, systemBaseUri)
+			req.Header.Set(tenantIdHeader, "a12be5")
+			req.Header.Set(signatureHeader, base64Signature(systemBaseUri+"a12be5", signatureKey))
+			for k, v := range tc.fromClient {
+				req.Header.Add(k, v)
+			}
+			for k, v := range tc.fromProxy {
+				req.Header.Add(k, v)
+			}
+			handlerSpy := handlerSpy{}
+
+			tenant.AddToCtx("", signatureKey, tenant.TrustedProxies(trustedProxyNetwork), tenant.TrustedProxyHeaders(tc.proxyHeaders))(&handlerSpy).ServeHTTP(httptest.NewRecorder(), req)
+
+			if err := handlerSpy.assertInitiatorSystemBaseUriIs(tc.wantInitiator); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestTrustedProxiesWithoutTrustedProxyHeaders_AddToCtx_Panics(t *testing.T) {
+	testcases := map[string]tenant.Option{
+		"TrustedProxies": tenant.TrustedProxies(trustedProxyNetwork),
+		"TrustProxy":     tenant.TrustProxy(func(*http.Request) bool { return true }),
+	}
+
+	for name, option := range testcases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+
+			tenant.AddToCtx("", signatureKey, option)
+		})
+	}
+}
+
+func TestInvalidForwardedHeaders_UsesSystemBaseUriHeader(t *testing.T) {
+	testcases := map[string]struct {
+		proxyHeaders tenant.ProxyHeaders
+		headers      map[string]string
+		options      []tenant.Option
+	}{
+		"UnsupportedProto":  {tenant.ProxyHeadersForwarded, map[string]string{forwardedHeader: "proto=javascript;host=forwarded.example.com"}, nil},
+		"HostWithPath":      {tenant.ProxyHeadersForwarded, map[string]string{forwardedHeader: `host="forwarded.example.com/evil"`}, nil},
+		"HostWithUserinfo":  {tenant.ProxyHeadersXForwarded, map[string]string{xForwardedHostHeader: "user@xforwarded.example.com"}, nil},
+		"HostNotAllowed":    {tenant.ProxyHeadersForwarded, map[string]string{forwardedHeader: "host=evil.example.org"}, []tenant.Option{tenant.AllowedHosts("*.example.com")}},
+		"XHostNotAllowed":   {tenant.ProxyHeadersXForwarded, map[string]string{xForwardedHostHeader: "evil.example.org"}, []tenant.Option{tenant.AllowedHosts("*.example.com")}},
+		"MalformedPort":     {tenant.ProxyHeadersForwarded, map[string]string{forwardedHeader: `host="forwarded.example.com:44a"`}, nil},
+		"MalformedHeader":   {tenant.ProxyHeadersForwarded, map[string]string{forwardedHeader: `host="forwarded.example.com`}, nil},
+		"UnbracketedIpv6":   {tenant.ProxyHeadersXForwarded, map[string]string{xForwardedHostHeader: "2001:db8::1"}, nil},
+		"ProtoOfXForwarded": {tenant.ProxyHeadersXForwarded, map[string]string{xForwardedHostHeader: "xforwarded.example.com", "x-forwarded-proto": "ftp"}, nil},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			const systemBaseUri = "https://sample.example.com"
+			req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+			req.RemoteAddr = trustedProxyAddr
+			req.Header.Set(systemBaseUriHeader, systemBaseUri)
+			req.Header.Set(tenantIdHeader, "a12be5")
+			req.Header.Set(signatureHeader, base64Signature(systemBaseUri+"a12be5", signatureKey))
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			handlerSpy := handlerSpy{}
+
+			tenant.AddToCtx("", signatureKey, append(tc.options, tenant.TrustedProxies(trustedProxyNetwork), tenant.TrustedProxyHeaders(tc.proxyHeaders))...)(&handlerSpy).ServeHTTP(httptest.NewRecorder(), req)
+
+			if err := handlerSpy.assertInitiatorSystemBaseUriIs(systemBaseUri); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestForwardedHeadersFromUntrustedProxy_UsesSystemBaseUriHeader(t *testing.T) {
+	testcases := map[string][]tenant.Option{
+		"NoTrustedProxies":     nil,
+		"OtherTrustedProxies":  {tenant.TrustedProxies("192.168.0.0/16", "10.0.0.2"), tenant.TrustedProxyHeaders(tenant.ProxyHeadersForwarded)},
+		"PredicateDeniesProxy": {tenant.TrustProxy(func(*http.Request) bool { return false }), tenant.TrustedProxyHeaders(tenant.ProxyHeadersForwarded)},
+	}
+
+	for name, options := range testcases {
+		t.Run(name, func(t *testing.T) {
+			const systemBaseUri = "https://sample.example.com"
+			req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+			req.RemoteAddr = trustedProxyAddr
+			req.Header.Set(systemBaseUriHeader, systemBaseUri)
+			req.Header.Set(tenantIdHeader, "a12be5")
+			req.Header.Set(signatureHeader, base64Signature(systemBaseUri+"a12be5", signatureKey))
+			req.Header.Set(forwardedHeader, "host=evil.example.com")
+			req.Header.Set(xForwardedHostHeader, "evil.example.com")
+			handlerSpy := handlerSpy{}
+
+			tenant.AddToCtx("", signatureKey, options...)(&handlerSpy).ServeHTTP(httptest.NewRecorder(), req)
+
+			if err := handlerSpy.assertInitiatorSystemBaseUriIs(systemBaseUri); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestForwardedHeadersFromTrustedProxy_UsesForwardedHeader(t *testing.T) {
+	testcases := map[string]struct {
+		remoteAddr string
+		options    []tenant.Option
+	}{
+		"Cidr":           {"10.1.2.3:4711", []tenant.Option{tenant.TrustedProxies("192.168.0.0/16", "10.0.0.0/8")}},
+		"SingleIp":       {"10.0.0.2:4711", []tenant.Option{tenant.TrustedProxies("10.0.0.2")}},
+		"Ipv6":           {"[2001:db8::1]:4711", []tenant.Option{tenant.TrustedProxies("2001:db8::/32")}},
+		"Predicate":      {"192.0.2.1:1234", []tenant.Option{tenant.TrustProxy(func(*http.Request) bool { return true })}},
+		"AnyOfMultiple":  {"10.0.0.2:4711", []tenant.Option{tenant.TrustProxy(func(*http.Request) bool { return false }), tenant.TrustedProxies("10.0.0.2")}},
+		"NoPortInRemote": {"10.0.0.2", []tenant.Option{tenant.TrustedProxies("10.0.0.2")}},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/myresource/sub", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set(forwardedHeader, "host=forwarded.example.com")
+			handlerSpy := handlerSpy{}
+
+			tenant.AddToCtx("", signatureKey, append(tc.options, tenant.TrustedProxyHeaders(tenant.ProxyHeadersForwarded))...)(&handlerSpy).ServeHTTP(httptest.NewRecorder(), req)
+
+			if err := handlerSpy.assertInitiatorSystemBaseUriIs("https://forwarded.example.com"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestInvalidTrustedProxy_TrustedProxies_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+
+	tenant.TrustedProxies("10.0.0.0/99")
+}
+
 func TestInitiatorSystemBaseUriHeader_EmptyForwardedHeadersNoSystemBaseUri(t *testing.T) {
 	req, err := http.NewRequest("GET", "/myresource/sub", nil)
 	if err != nil {
@@ -563,6 +785,8 @@ type handlerSpy struct {
 	errorReadingSystemBaseUri          error
 	errorReadingTenantId               error
 	errorReadingInitiatorSystemBaseUri error
+	signatureKeyId                     string
+	errorReadingSignatureKeyId         error
 	hasBeenCalled                      bool
 }
 
@@ -571,6 +795,7 @@ func (spy *handlerSpy) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	spy.systemBaseUri, spy.errorReadingSystemBaseUri = tenant.SystemBaseUriFromCtx(r.Context())
 	spy.tenantId, spy.errorReadingTenantId = tenant.IdFromCtx(r.Context())
 	spy.initiatorSystemBaseUri, spy.errorReadingInitiatorSystemBaseUri = tenant.InitiatorSystemBaseUriFromCtx(r.Context())
+	spy.signatureKeyId, spy.errorReadingSignatureKeyId = tenant.SignatureKeyIdFromCtx(r.Context())
 }
 
 func (spy *handlerSpy) assertBaseUriIs(expected string) error {
@@ -594,6 +819,13 @@ func (spy *handlerSpy) assertInitiatorSystemBaseUriIs(expected string) error {
 	return nil
 }
 
+func (spy *handlerSpy) assertSignatureKeyIdIs(expected string) error {
+	if spy.signatureKeyId != expected {
+		return fmt.Errorf("handler set wrong signatureKeyId on context: got %v want %v", spy.signatureKeyId, expected)
+	}
+	return nil
+}
+
 func (spy *handlerSpy) assertErrorReadingSystemBaseUri() error {
 	if spy.errorReadingSystemBaseUri == nil {
 		return fmt.Errorf("expected error while reading systemBaseUri from context")
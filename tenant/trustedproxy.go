@@ -0,0 +1,113 @@
+package tenant
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ProxyHeaders are the forwarding headers which the trusted proxies set, cf. TrustedProxyHeaders.
+type ProxyHeaders int
+
+const (
+	// ProxyHeadersForwarded is the standardized Forwarded header of RFC 7239.
+	ProxyHeadersForwarded ProxyHeaders = iota + 1
+	// ProxyHeadersXForwarded are the de-facto standard headers X-Forwarded-Host and X-Forwarded-Proto.
+	ProxyHeadersXForwarded
+)
+
+// TrustedProxyHeaders sets the forwarding headers which the trusted proxies set. Only these headers are used to
+// determine the InitiatorSystemBaseUri and the other forwarding headers are ignored, because they might have
+// been sent by the client. The option is required if proxies are trusted (cf. TrustedProxies).
+func TrustedProxyHeaders(h ProxyHeaders) Option {
+	return func(c *config) {
+		c.proxyHeaders = h
+	}
+}
+
+// TrustedProxies sets the proxies whose forwarding headers (cf. TrustedProxyHeaders) are used to determine the
+// InitiatorSystemBaseUri. Each proxy is given as CIDR like "10.0.0.0/8" or as single IP address.
+// A request is accepted from a trusted proxy if its RemoteAddr lies within one of the networks.
+//
+// By default no proxy is trusted and the InitiatorSystemBaseUri is the signed x-dv-baseuri, because
+// forwarding headers can be set by anyone who is able to send a request to the App. Only the last forwarded element
+// is used, so a trusted proxy must append an element with the host to the Forwarded header or overwrite the
+// X-Forwarded-Host header sent by the client, depending on TrustedProxyHeaders.
+//
+// Example:
+//	tenant.AddToCtx(os.Getenv("systemBaseUri"), signatureSecretKey,
+//		tenant.TrustedProxies("10.0.0.0/8"), tenant.TrustedProxyHeaders(tenant.ProxyHeadersXForwarded))
+//
+// TrustedProxies panics if a value is neither a valid CIDR nor a valid IP address.
+func TrustedProxies(cidrs ...string) Option {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		networks = append(networks, mustParseNetwork(cidr))
+	}
+	return TrustProxy(func(req *http.Request) bool {
+		ip := remoteIp(req)
+		if ip == nil {
+			return false
+		}
+		for _, n := range networks {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// TrustProxy sets a function which decides whether the request has been sent by a trusted proxy whose forwarding headers
+// are used to determine the InitiatorSystemBaseUri. Cf. TrustedProxies.
+//
+// If the option is given multiple times, or together with TrustedProxies, a proxy is trusted if any of them trusts it.
+func TrustProxy(isTrusted func(req *http.Request) bool) Option {
+	return func(c *config) {
+		c.trustsProxies = true
+		previous := c.isTrustedProxy
+		c.isTrustedProxy = func(req *http.Request) bool {
+			return previous(req) || isTrusted(req)
+		}
+	}
+}
+
+func trustNoProxy(*http.Request) bool {
+	return false
+}
+
+// checkTrustedProxies panics if proxies are trusted but it hasn't been configured which forwarding headers they set.
+func (c *config) checkTrustedProxies() {
+	if c.trustsProxies && c.proxyHeaders != ProxyHeadersForwarded && c.proxyHeaders != ProxyHeadersXForwarded {
+		panic("tenant: TrustedProxies and TrustProxy require TrustedProxyHeaders to select the forwarding headers which the proxies set")
+	}
+}
+
+func mustParseNetwork(cidr string) *net.IPNet {
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			panic(fmt.Sprintf("tenant: invalid trusted proxy '%v'", cidr))
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(fmt.Sprintf("tenant: invalid trusted proxy '%v': %v", cidr, err))
+	}
+	return n
+}
+
+func remoteIp(req *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
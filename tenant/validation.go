@@ -0,0 +1,148 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrSystemBaseUriInvalid is the reason of a VerificationError if the systemBaseUri isn't an absolute https URL
+	// without path, query and userinfo or if its host isn't allowed (cf. AllowedHosts). For loopback hosts like
+	// localhost http is accepted as well.
+	ErrSystemBaseUriInvalid = errors.New("systemBaseUri is invalid")
+	// ErrTenantIdInvalid is the reason of a VerificationError if the tenant id doesn't match the expected format (cf. TenantIdPattern).
+	ErrTenantIdInvalid = errors.New("tenant id is invalid")
+)
+
+// DefaultTenantIdPattern is the format of tenant ids which is accepted by default.
+var DefaultTenantIdPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// invalidValueError describes why a value is invalid and matches the kind of error with errors.Is
+type invalidValueError struct {
+	kind   error
+	detail string
+}
+
+func (e *invalidValueError) Error() string {
+	return fmt.Sprintf("%v: %v", e.kind, e.detail)
+}
+
+func (e *invalidValueError) Is(target error) bool {
+	return target == e.kind
+}
+
+// AllowedHosts restricts the hosts of the systemBaseUri which are accepted. A pattern is either a host like "example.com",
+// which has to match exactly, or a suffix rule like "*.example.com", which matches all subdomains of example.com.
+//
+// By default all hosts are accepted.
+func AllowedHosts(patterns ...string) Option {
+	return func(c *config) {
+		for _, p := range patterns {
+			c.allowedHosts = append(c.allowedHosts, strings.ToLower(p))
+		}
+	}
+}
+
+// TenantIdPattern sets the format of tenant ids which are accepted. Default is DefaultTenantIdPattern.
+func TenantIdPattern(pattern *regexp.Regexp) Option {
+	return func(c *config) {
+		c.tenantIdPattern = pattern
+	}
+}
+
+// normalizeSystemBaseUri validates the systemBaseUri and returns it in the form https://host[:port] with lowercase
+// host, without trailing slash and without the default port. http is accepted for loopback hosts, so that an App
+// can be run locally without TLS.
+func (c *config) normalizeSystemBaseUri(systemBaseUri string) (string, error) {
+	if u, err := url.Parse(systemBaseUri); err == nil && strings.EqualFold(u.Scheme, "http") && isLoopback(u.Hostname()) {
+		return c.normalizeBaseUri(systemBaseUri, "http")
+	}
+	return c.normalizeBaseUri(systemBaseUri, "https")
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// normalizeInitiatorSystemBaseUri validates the initiatorSystemBaseUri derived from forwarding headers like
+// normalizeSystemBaseUri but accepts http as well, because the proxy might have been called by http.
+func (c *config) normalizeInitiatorSystemBaseUri(initiatorSystemBaseUri string) (string, error) {
+	return c.normalizeBaseUri(initiatorSystemBaseUri, "https", "http")
+}
+
+var defaultPorts = map[string]string{"https": "443", "http": "80"}
+
+func (c *config) normalizeBaseUri(baseUri string, schemes ...string) (string, error) {
+	invalid := func(format string, v ...interface{}) error {
+		return &invalidValueError{kind: ErrSystemBaseUriInvalid, detail: fmt.Sprintf(format, v...)}
+	}
+	u, err := url.Parse(baseUri)
+	if err != nil {
+		return "", invalid("'%v' is no URL", baseUri)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !contains(schemes, scheme) {
+		return "", invalid("'%v' is no absolute %v URL", baseUri, strings.Join(schemes, " or "))
+	}
+	if u.User != nil || u.Opaque != "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.ForceQuery || u.Fragment != "" {
+		return "", invalid("'%v' must not contain userinfo, path, query or fragment", baseUri)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return "", invalid("'%v' contains no host", baseUri)
+	}
+	if !c.isAllowedHost(host) {
+		return "", invalid("host '%v' is not allowed", host)
+	}
+	authority := host
+	if strings.Contains(host, ":") {
+		// IPv6 address
+		authority = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[scheme] {
+		authority = net.JoinHostPort(host, port)
+	}
+	return scheme + "://" + authority, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *config) isAllowedHost(host string) bool {
+	return len(c.allowedHosts) == 0 || matchesHost(c.allowedHosts, host)
+}
+
+// matchesHost reports whether the lowercase host matches one of the lowercase patterns (cf. AllowedHosts)
+func matchesHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "*.") {
+			if strings.HasSuffix(host, p[1:]) {
+				return true
+			}
+		} else if host == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *config) validateTenantId(tenantId string) error {
+	if !c.tenantIdPattern.MatchString(tenantId) {
+		return &invalidValueError{kind: ErrTenantIdInvalid, detail: fmt.Sprintf("'%v' doesn't match %v", tenantId, c.tenantIdPattern)}
+	}
+	return nil
+}
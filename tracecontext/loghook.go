@@ -0,0 +1,28 @@
+package tracecontext
+
+import (
+	"context"
+
+	"github.com/d-velop/dvelop-sdk-go/otellog"
+)
+
+// LogHook writes the trace-id, span-id and parent span-id from the context into the log event,
+// so that log backends can correlate the events of a request and rebuild the call tree across services.
+//
+// Example:
+//	func main() {
+//		otellog.RegisterHook(tracecontext.LogHook)
+//		mux := http.NewServeMux()
+//		mux.Handle("/hello", tracecontext.AddToCtx()(helloHandler()))
+//	}
+func LogHook(ctx context.Context, e *otellog.Event) {
+	if traceId, err := TraceIdFromCtx(ctx); err == nil {
+		e.TraceId = traceId
+	}
+	if spanId, err := SpanIdFromCtx(ctx); err == nil {
+		e.SpanId = spanId
+	}
+	if parentSpanId, err := ParentSpanIdFromCtx(ctx); err == nil {
+		e.ParentSpanId = parentSpanId
+	}
+}
@@ -0,0 +1,32 @@
+package tracecontext_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/otellog"
+	"github.com/d-velop/dvelop-sdk-go/tracecontext"
+)
+
+func TestContextWithTraceContext_LogHook_WritesIdsIntoEvent(t *testing.T) {
+	ctx := tracecontext.WithTraceIdCtx(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	ctx = tracecontext.WithSpanIdCtx(ctx, "b7ad6b7169203331")
+	ctx = tracecontext.WithParentSpanIdCtx(ctx, "00f067aa0ba902b7")
+	e := otellog.Event{}
+
+	tracecontext.LogHook(ctx, &e)
+
+	assertString(t, "4bf92f3577b34da6a3ce929d0e0e4736", e.TraceId)
+	assertString(t, "b7ad6b7169203331", e.SpanId)
+	assertString(t, "00f067aa0ba902b7", e.ParentSpanId)
+}
+
+func TestEmptyContext_LogHook_LeavesEventUnchanged(t *testing.T) {
+	e := otellog.Event{TraceId: "4bf92f3577b34da6a3ce929d0e0e4736"}
+
+	tracecontext.LogHook(context.Background(), &e)
+
+	assertString(t, "4bf92f3577b34da6a3ce929d0e0e4736", e.TraceId)
+	assertString(t, "", e.SpanId)
+	assertString(t, "", e.ParentSpanId)
+}
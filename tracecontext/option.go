@@ -0,0 +1,43 @@
+package tracecontext
+
+// An Option configures the behaviour of AddToCtx.
+type Option func(*config)
+
+type config struct {
+	sample SamplerFunc
+}
+
+func newConfig(options []Option) *config {
+	c := &config{
+		sample: ParentBased(AlwaysSample()),
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+// Sampler sets the function which decides whether a request is sampled. Default is ParentBased(AlwaysSample()),
+// which keeps the decision of the caller and samples all new traces.
+//
+// Example:
+//	// sample 10 percent of the new traces and respect the decision of the caller
+//	tracecontext.AddToCtx(tracecontext.Sampler(tracecontext.ParentBased(tracecontext.TraceIdRatio(0.1))))
+func Sampler(s SamplerFunc) Option {
+	return func(c *config) {
+		c.sample = s
+	}
+}
+
+// traceFlags returns the trace-flags of a request with the given trace-id. The flags of the parent other than
+// the sampled flag are kept.
+func (c *config) traceFlags(traceId string, parent *Traceparent) byte {
+	var flags byte
+	if parent != nil {
+		flags = parent.traceFlags &^ FlagSampled
+	}
+	if c.sample(traceId, parent) {
+		flags |= FlagSampled
+	}
+	return flags
+}
@@ -0,0 +1,56 @@
+package tracecontext
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// A SamplerFunc decides whether a request is sampled, that is whether its spans are recorded.
+//
+// traceId is the trace-id of the request and parent is the Traceparent of the caller or nil if the request starts a new trace.
+type SamplerFunc func(traceId string, parent *Traceparent) bool
+
+// AlwaysSample samples all requests.
+func AlwaysSample() SamplerFunc {
+	return func(string, *Traceparent) bool {
+		return true
+	}
+}
+
+// NeverSample samples no request.
+func NeverSample() SamplerFunc {
+	return func(string, *Traceparent) bool {
+		return false
+	}
+}
+
+// TraceIdRatio samples the given ratio of the traces, e.g. 0.1 for 10 percent of the traces.
+//
+// The decision is derived from the random part of the trace-id, so all services which use the same ratio
+// take the same decision for a trace. A ratio >= 1 samples all traces and a ratio <= 0 none.
+func TraceIdRatio(ratio float64) SamplerFunc {
+	if ratio >= 1 {
+		return AlwaysSample()
+	}
+	if ratio <= 0 {
+		return NeverSample()
+	}
+	upperBound := uint64(ratio * (1 << 63))
+	return func(traceId string, _ *Traceparent) bool {
+		id, err := hex.DecodeString(traceId)
+		if err != nil || len(id) != 16 {
+			return false
+		}
+		return binary.BigEndian.Uint64(id[8:])>>1 < upperBound
+	}
+}
+
+// ParentBased respects the sampling decision of the caller and uses root for requests which start a new trace.
+func ParentBased(root SamplerFunc) SamplerFunc {
+	return func(traceId string, parent *Traceparent) bool {
+		if parent != nil {
+			return parent.IsSampled()
+		}
+		return root(traceId, parent)
+	}
+}
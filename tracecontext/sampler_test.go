@@ -0,0 +1,81 @@
+package tracecontext_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/tracecontext"
+)
+
+func TestAlwaysSample_SamplesAllTraces(t *testing.T) {
+	if !tracecontext.AlwaysSample()("4bf92f3577b34da6a3ce929d0e0e4736", nil) {
+		t.Error("trace should be sampled")
+	}
+}
+
+func TestNeverSample_SamplesNoTrace(t *testing.T) {
+	if tracecontext.NeverSample()("4bf92f3577b34da6a3ce929d0e0e4736", nil) {
+		t.Error("trace should not be sampled")
+	}
+}
+
+func TestTraceIdRatio_DecidesByRandomPartOfTraceId(t *testing.T) {
+	testcases := []struct {
+		ratio   float64
+		traceId string
+		want    bool
+	}{
+		{0.5, "4bf92f3577b34da60000000000000000", true},
+		{0.5, "4bf92f3577b34da67fffffffffffffff", true},
+		{0.5, "4bf92f3577b34da68000000000000000", false},
+		{0.25, "ffffffffffffffff3fffffffffffffff", true},
+		{0.25, "00000000000000004000000000000000", false},
+		{1, "4bf92f3577b34da6ffffffffffffffff", true},
+		{0, "4bf92f3577b34da60000000000000000", false},
+		{0.5, "invalid", false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(fmt.Sprintf("%v %v", tc.ratio, tc.traceId), func(t *testing.T) {
+			if got := tracecontext.TraceIdRatio(tc.ratio)(tc.traceId, nil); got != tc.want {
+				t.Errorf("wrong sampling decision: got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTraceIdRatio_SamplesApproximatelyTheRatio(t *testing.T) {
+	sample := tracecontext.TraceIdRatio(0.3)
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		traceId, _ := tracecontext.NewTraceId()
+		if sample(traceId, nil) {
+			sampled++
+		}
+	}
+
+	if sampled < 2500 || sampled > 3500 {
+		t.Errorf("wrong number of sampled traces: got %v want approximately %v", sampled, 3000)
+	}
+}
+
+func TestParentBased_UsesDecisionOfParent(t *testing.T) {
+	sampledParent, _ := tracecontext.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	notSampledParent, _ := tracecontext.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	if !tracecontext.ParentBased(tracecontext.NeverSample())("4bf92f3577b34da6a3ce929d0e0e4736", sampledParent) {
+		t.Error("trace of sampled parent should be sampled")
+	}
+	if tracecontext.ParentBased(tracecontext.AlwaysSample())("4bf92f3577b34da6a3ce929d0e0e4736", notSampledParent) {
+		t.Error("trace of not sampled parent should not be sampled")
+	}
+}
+
+func TestParentBased_UsesRootSamplerForNewTrace(t *testing.T) {
+	if tracecontext.ParentBased(tracecontext.NeverSample())("4bf92f3577b34da6a3ce929d0e0e4736", nil) {
+		t.Error("new trace should not be sampled")
+	}
+	if !tracecontext.ParentBased(tracecontext.AlwaysSample())("4bf92f3577b34da6a3ce929d0e0e4736", nil) {
+		t.Error("new trace should be sampled")
+	}
+}
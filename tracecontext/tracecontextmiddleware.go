@@ -4,43 +4,67 @@
 //
 // Logstatements should log the trace-id and span-id in every statement to correlate
 // the statements to a specific request. This simplifies the tracking of
-// a request through a system which serves multiple concurrent requests.
+// a request through a system which serves multiple concurrent requests (cf. LogHook).
+// Transport passes the trace context on to downstream services.
 package tracecontext
 
 import (
 	"context"
 	"errors"
 	"net/http"
+	"strings"
 )
 
 type contextKey string
 
 const traceIdCtxKey = contextKey("traceId")
 const spanIdCtxKey = contextKey("spanId")
+const parentSpanIdCtxKey = contextKey("parentSpanId")
+const traceFlagsCtxKey = contextKey("traceFlags")
+const tracestateCtxKey = contextKey("tracestate")
 const traceparentHeader = "traceparent"
+const tracestateHeader = "tracestate"
 
 // AddToCtx reads the http header traceparent from the current request
-// and stores the trace-id in the context. The span-id is regenerated on request.
+// and stores the trace-id, the trace-flags and the span-id of the caller as parent span-id in the context.
+// The span-id is regenerated on request.
 // If the request doesn't have an existing trace-id a new one is generated.
-func AddToCtx() func(http.Handler) http.Handler {
+// The sampled flag is set according to the Sampler, which by default keeps the decision of the caller (cf. IsSampled).
+// The http header tracestate is stored in the context if the traceparent and the tracestate are valid.
+func AddToCtx(options ...Option) func(http.Handler) http.Handler {
+	c := newConfig(options)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 			ctx := req.Context()
+			parent := getParent(req.Header)
 
-			if traceId, err := getTraceId(req.Header); err == nil {
+			if traceId, err := getTraceId(parent); err == nil {
 				ctx = WithTraceIdCtx(ctx, traceId)
+				ctx = WithTraceFlagsCtx(ctx, c.traceFlags(traceId, parent))
 			}
 
 			if spanId, err := NewSpanId(); err == nil {
 				ctx = WithSpanIdCtx(ctx, spanId)
 			}
 
+			if parent != nil {
+				ctx = WithParentSpanIdCtx(ctx, parent.ParentId())
+			}
+
+			// cf. https://w3c.github.io/trace-context/#no-traceparent-received
+			if parent != nil {
+				if tracestate, err := getTracestate(req.Header); err == nil && tracestate.Len() > 0 {
+					ctx = WithTracestateCtx(ctx, tracestate)
+				}
+			}
+
 			next.ServeHTTP(rw, req.WithContext(ctx))
 		})
 	}
 }
 
-// TraceparentFromCtx reads the current trace-id and span-id from the context and builds the traceparent header value.
+// TraceparentFromCtx reads the current trace-id, span-id and trace-flags from the context and builds the traceparent header value.
+// If there are no trace-flags on the context the sampled flag is set.
 func TraceparentFromCtx(ctx context.Context) (string, error) {
 	traceId, err := TraceIdFromCtx(ctx)
 	if err != nil {
@@ -55,6 +79,9 @@ func TraceparentFromCtx(ctx context.Context) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if traceFlags, err := TraceFlagsFromCtx(ctx); err == nil {
+		tp.traceFlags = traceFlags
+	}
 
 	return tp.String(), nil
 }
@@ -77,6 +104,47 @@ func SpanIdFromCtx(ctx context.Context) (string, error) {
 	return spanId, nil
 }
 
+// ParentSpanIdFromCtx reads the span-id of the caller from the context.
+//
+// An error is returned if the request started a new trace and thus has no parent.
+func ParentSpanIdFromCtx(ctx context.Context) (string, error) {
+	parentSpanId, ok := ctx.Value(parentSpanIdCtxKey).(string)
+	if !ok {
+		return "", errors.New("no parentSpanId on context")
+	}
+	return parentSpanId, nil
+}
+
+// TraceFlagsFromCtx reads the current trace-flags from the context.
+func TraceFlagsFromCtx(ctx context.Context) (byte, error) {
+	traceFlags, ok := ctx.Value(traceFlagsCtxKey).(byte)
+	if !ok {
+		return 0, errors.New("no traceFlags on context")
+	}
+	return traceFlags, nil
+}
+
+// IsSampled returns true if the current request is sampled, so that loggers and exporters can decide whether
+// to record its spans. A context without trace-flags is regarded as sampled.
+func IsSampled(ctx context.Context) bool {
+	traceFlags, err := TraceFlagsFromCtx(ctx)
+	if err != nil {
+		return true
+	}
+	return traceFlags&FlagSampled != 0
+}
+
+// TracestateFromCtx reads the current tracestate from the context.
+//
+// Propagate its string representation in the http header tracestate alongside the traceparent from TraceparentFromCtx.
+func TracestateFromCtx(ctx context.Context) (*Tracestate, error) {
+	tracestate, ok := ctx.Value(tracestateCtxKey).(*Tracestate)
+	if !ok {
+		return nil, errors.New("no tracestate on context")
+	}
+	return tracestate, nil
+}
+
 // WithTraceIdCtx returns a new context.Context with the given trace id
 func WithTraceIdCtx(ctx context.Context, traceId string) context.Context {
 	return context.WithValue(ctx, traceIdCtxKey, traceId)
@@ -87,16 +155,43 @@ func WithSpanIdCtx(ctx context.Context, spanId string) context.Context {
 	return context.WithValue(ctx, spanIdCtxKey, spanId)
 }
 
-func getTraceId(header http.Header) (string, error) {
+// WithParentSpanIdCtx returns a new context.Context with the given parent span id
+func WithParentSpanIdCtx(ctx context.Context, parentSpanId string) context.Context {
+	return context.WithValue(ctx, parentSpanIdCtxKey, parentSpanId)
+}
+
+// WithTraceFlagsCtx returns a new context.Context with the given trace-flags
+func WithTraceFlagsCtx(ctx context.Context, traceFlags byte) context.Context {
+	return context.WithValue(ctx, traceFlagsCtxKey, traceFlags)
+}
+
+// WithTracestateCtx returns a new context.Context with the given tracestate
+func WithTracestateCtx(ctx context.Context, tracestate *Tracestate) context.Context {
+	return context.WithValue(ctx, tracestateCtxKey, tracestate)
+}
+
+// getParent returns the traceparent of the request or nil if the request has no valid traceparent.
+func getParent(header http.Header) *Traceparent {
 	if s := header.Get(traceparentHeader); s != "" {
 		if t, err := ParseTraceparent(s); err == nil {
-			return t.TraceId(), nil
+			return t
 		}
 	}
+	return nil
+}
+
+func getTraceId(parent *Traceparent) (string, error) {
+	if parent != nil {
+		return parent.TraceId(), nil
+	}
 
 	traceId, err := NewTraceId()
 	if err != nil {
 		return "", err
 	}
 	return traceId, nil
-}
\ No newline at end of file
+}
+
+func getTracestate(header http.Header) (*Tracestate, error) {
+	return ParseTracestate(strings.Join(header.Values(tracestateHeader), ","))
+}
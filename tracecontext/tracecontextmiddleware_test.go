@@ -80,9 +80,32 @@ func TestTraceparentHeader_SetGivenTraceIdToCtxAndGeneratesNewSpanId(t *testing.
 	}
 }
 
-func TestTraceparentHeader_GetSameTraceparentWithNewSpanIdAndFlags1(t *testing.T) {
+func TestTraceparentHeader_GetSameTraceparentWithNewSpanIdAndSameFlags(t *testing.T) {
+	testcases := map[string]bool{"00": false, "01": true, "03": true, "02": false}
+
+	for flags, sampled := range testcases {
+		t.Run(flags, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/myresource/sub", nil)
+			req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-"+flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			innerHandler := handlerSpy{}
+
+			tracecontext.AddToCtx()(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+			if err := innerHandler.assertTraceparentIs(fmt.Sprintf("00-4bf92f3577b34da6a3ce929d0e0e4736-%v-%v", innerHandler.spanId, flags)); err != nil {
+				t.Error(err)
+			}
+			if innerHandler.sampled != sampled {
+				t.Errorf("wrong sampling decision: got %v want %v", innerHandler.sampled, sampled)
+			}
+		})
+	}
+}
+
+func TestMissingTraceparentHeader_SamplesNewTrace(t *testing.T) {
 	req, err := http.NewRequest("GET", "/myresource/sub", nil)
-	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -90,11 +113,81 @@ func TestTraceparentHeader_GetSameTraceparentWithNewSpanIdAndFlags1(t *testing.T
 
 	tracecontext.AddToCtx()(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
 
-	if err := innerHandler.assertTraceparentIs(fmt.Sprintf("00-4bf92f3577b34da6a3ce929d0e0e4736-%v-01", innerHandler.spanId)); err != nil {
+	if !innerHandler.sampled {
+		t.Error("new trace should be sampled")
+	}
+	if err := innerHandler.assertTraceparentIs(fmt.Sprintf("00-%v-%v-01", innerHandler.traceId, innerHandler.spanId)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSampler_DecidesSamplingOfNewTrace(t *testing.T) {
+	req, err := http.NewRequest("GET", "/myresource/sub", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	innerHandler := handlerSpy{}
+
+	tracecontext.AddToCtx(tracecontext.Sampler(tracecontext.ParentBased(tracecontext.NeverSample())))(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if innerHandler.sampled {
+		t.Error("new trace should not be sampled")
+	}
+	if err := innerHandler.assertTraceparentIs(fmt.Sprintf("00-%v-%v-00", innerHandler.traceId, innerHandler.spanId)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSampler_OverridesSampledFlagOfCallerAndKeepsOtherFlags(t *testing.T) {
+	req, err := http.NewRequest("GET", "/myresource/sub", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	innerHandler := handlerSpy{}
+
+	tracecontext.AddToCtx(tracecontext.Sampler(tracecontext.NeverSample()))(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := innerHandler.assertTraceparentIs(fmt.Sprintf("00-4bf92f3577b34da6a3ce929d0e0e4736-%v-02", innerHandler.spanId)); err != nil {
 		t.Error(err)
 	}
 }
 
+func TestTraceparentHeader_SetSpanIdOfCallerAsParentSpanIdToCtx(t *testing.T) {
+	req, err := http.NewRequest("GET", "/myresource/sub", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	innerHandler := handlerSpy{}
+
+	tracecontext.AddToCtx()(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err = innerHandler.assertParentSpanIdIs("00f067aa0ba902b7"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMissingTraceparentHeader_DoesNotSetParentSpanIdToCtx(t *testing.T) {
+	req, err := http.NewRequest("GET", "/myresource/sub", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	innerHandler := handlerSpy{}
+
+	tracecontext.AddToCtx()(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err = innerHandler.assertParentSpanIdIs(""); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNoParentSpanIdOnContext_WithParentSpanIdCtx_ReturnsContextWithParentSpanId(t *testing.T) {
+	ctx := tracecontext.WithParentSpanIdCtx(context.Background(), "00f067aa0ba902b7")
+	parentSpanId, _ := tracecontext.ParentSpanIdFromCtx(ctx)
+	assertString(t, "00f067aa0ba902b7", parentSpanId)
+}
+
 func TestNoTraceIdOnContext_WithTraceIdCtx_ReturnsContextWithTraceId(t *testing.T) {
 	ctx := tracecontext.WithTraceIdCtx(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736")
 	traceId, _ := tracecontext.TraceIdFromCtx(ctx)
@@ -114,18 +207,116 @@ func TestGivenTraceIdAndSpanIdOnContext_TraceParentFromCtx_ReturnsTraceparentFro
 	assertString(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceparent)
 }
 
+func TestTracestateHeader_SetTracestateToCtx(t *testing.T) {
+	req, err := http.NewRequest("GET", "/myresource/sub", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Add("tracestate", "rojo=00f067aa0ba902b7")
+	req.Header.Add("tracestate", "congo=t61rcWkgMzE")
+	innerHandler := handlerSpy{}
+
+	tracecontext.AddToCtx()(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err = innerHandler.assertTracestateIs("rojo=00f067aa0ba902b7,congo=t61rcWkgMzE"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTracestateHeaderWithInvalidTraceparent_DoesNotSetTracestateToCtx(t *testing.T) {
+	req, err := http.NewRequest("GET", "/myresource/sub", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("traceparent", "invalid")
+	req.Header.Set("tracestate", "rojo=00f067aa0ba902b7")
+	innerHandler := handlerSpy{}
+
+	tracecontext.AddToCtx()(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err = innerHandler.assertTracestateIs(""); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInvalidTracestateHeader_DoesNotSetTracestateToCtx(t *testing.T) {
+	req, err := http.NewRequest("GET", "/myresource/sub", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "Rojo=1,rojo=2")
+	innerHandler := handlerSpy{}
+
+	tracecontext.AddToCtx()(&innerHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if err = innerHandler.assertTracestateIs(""); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTraceFlagsOnContext_TraceparentFromCtx_ReturnsTraceparentWithTraceFlags(t *testing.T) {
+	ctx := tracecontext.WithSpanIdCtx(context.Background(), "00f067aa0ba902b7")
+	ctx = tracecontext.WithTraceIdCtx(ctx, "4bf92f3577b34da6a3ce929d0e0e4736")
+	ctx = tracecontext.WithTraceFlagsCtx(ctx, 0)
+	traceparent, _ := tracecontext.TraceparentFromCtx(ctx)
+	assertString(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", traceparent)
+}
+
+func TestIsSampled(t *testing.T) {
+	if !tracecontext.IsSampled(context.Background()) {
+		t.Error("context without trace-flags should be sampled")
+	}
+	if tracecontext.IsSampled(tracecontext.WithTraceFlagsCtx(context.Background(), 0x02)) {
+		t.Error("context without sampled flag should not be sampled")
+	}
+	if !tracecontext.IsSampled(tracecontext.WithTraceFlagsCtx(context.Background(), 0x01)) {
+		t.Error("context with sampled flag should be sampled")
+	}
+}
+
+func TestNoTracestateOnContext_WithTracestateCtx_ReturnsContextWithTracestate(t *testing.T) {
+	ts, _ := tracecontext.ParseTracestate("rojo=00f067aa0ba902b7")
+	ctx := tracecontext.WithTracestateCtx(context.Background(), ts)
+	fromCtx, _ := tracecontext.TracestateFromCtx(ctx)
+	assertString(t, "rojo=00f067aa0ba902b7", fromCtx.String())
+}
+
 type handlerSpy struct {
 	hasBeenCalled bool
 	traceparent   string
 	traceId       string
 	spanId        string
+	parentSpanId  string
+	tracestate    string
+	sampled       bool
 }
 
 func (spy *handlerSpy) ServeHTTP(_ http.ResponseWriter, r *http.Request) {
 	spy.hasBeenCalled = true
 	spy.traceId, _ = tracecontext.TraceIdFromCtx(r.Context())
 	spy.spanId, _ = tracecontext.SpanIdFromCtx(r.Context())
+	spy.parentSpanId, _ = tracecontext.ParentSpanIdFromCtx(r.Context())
 	spy.traceparent, _ = tracecontext.TraceparentFromCtx(r.Context())
+	spy.sampled = tracecontext.IsSampled(r.Context())
+	if ts, err := tracecontext.TracestateFromCtx(r.Context()); err == nil {
+		spy.tracestate = ts.String()
+	}
+}
+
+func (spy *handlerSpy) assertParentSpanIdIs(expected string) error {
+	if spy.parentSpanId != expected {
+		return fmt.Errorf("handler set wrong parentSpanId on context: got %v want %v", spy.parentSpanId, expected)
+	}
+	return nil
+}
+
+func (spy *handlerSpy) assertTracestateIs(expected string) error {
+	if spy.tracestate != expected {
+		return fmt.Errorf("handler set wrong tracestate on context: got %v want %v", spy.tracestate, expected)
+	}
+	return nil
 }
 
 func (spy *handlerSpy) assertTraceparentIs(expected string) error {
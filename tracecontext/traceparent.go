@@ -7,6 +7,9 @@ import (
 	"strings"
 )
 
+// FlagSampled is the trace-flag which indicates that the caller may have recorded the trace.
+const FlagSampled byte = 0x01
+
 // Traceparent defines the header used for distributed tracing. A Traceparent is
 // based on the W3C trace-context specification available at
 // https://w3c.github.io/trace-context/#traceparent-header.
@@ -27,6 +30,16 @@ func (t *Traceparent) ParentId() string {
 	return hex.EncodeToString(t.parentId[:])
 }
 
+// TraceFlags returns the trace-flags.
+func (t *Traceparent) TraceFlags() byte {
+	return t.traceFlags
+}
+
+// IsSampled returns true if the sampled flag is set.
+func (t *Traceparent) IsSampled() bool {
+	return t.traceFlags&FlagSampled != 0
+}
+
 // String returns the string representation of the traceparent.
 func (t *Traceparent) String() string {
 	return hex.EncodeToString([]byte{t.version}) + "-" +
@@ -53,11 +66,11 @@ func NewSpanId() (string, error) {
 	return hex.EncodeToString(id[:]), nil
 }
 
-// NewTraceparent creates a new Traceparent with given trace-id and parent-id.
+// NewTraceparent creates a new Traceparent with given trace-id and parent-id. The sampled flag is set.
 func NewTraceparent(traceId string, parentId string) (*Traceparent, error) {
 	t := &Traceparent{
 		version:    0,
-		traceFlags: 1,
+		traceFlags: FlagSampled,
 	}
 
 	ti, err := hex.DecodeString(traceId)
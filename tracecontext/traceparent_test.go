@@ -142,3 +142,15 @@ func assertString(t *testing.T, actual string, expected string) {
 		t.Errorf("\ngot   :'%v'\nwanted:'%v'", actual, expected)
 	}
 }
+
+func TestTraceparentWithFlags_IsSampled_ReturnsSampledFlag(t *testing.T) {
+	sampled, _ := tracecontext.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03")
+	notSampled, _ := tracecontext.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-02")
+
+	if !sampled.IsSampled() || sampled.TraceFlags() != 0x03 {
+		t.Errorf("traceparent should be sampled: got flags %v", sampled.TraceFlags())
+	}
+	if notSampled.IsSampled() || notSampled.TraceFlags() != 0x02 {
+		t.Errorf("traceparent should not be sampled: got flags %v", notSampled.TraceFlags())
+	}
+}
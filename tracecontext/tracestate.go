@@ -0,0 +1,138 @@
+package tracecontext
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxTracestateMembers is the maximum number of list-members of a tracestate.
+const MaxTracestateMembers = 32
+
+var (
+	tracestateKey   = regexp.MustCompile(`^([a-z][a-z0-9_*/-]{0,255}|[a-z0-9][a-z0-9_*/-]{0,240}@[a-z][a-z0-9_*/-]{0,13})$`)
+	tracestateValue = regexp.MustCompile(`^[\x20-\x2b\x2d-\x3c\x3e-\x7e]{0,255}[\x21-\x2b\x2d-\x3c\x3e-\x7e]$`)
+)
+
+type tracestateMember struct {
+	key   string
+	value string
+}
+
+// Tracestate defines the header used to carry vendor-specific trace data. A Tracestate is
+// based on the W3C trace-context specification available at
+// https://w3c.github.io/trace-context/#tracestate-header.
+//
+// A Tracestate is immutable. Set and Delete return a modified copy, so a Tracestate can safely be shared
+// e.g. by storing it in a context. The nil value is an empty Tracestate.
+type Tracestate struct {
+	members []tracestateMember
+}
+
+// ParseTracestate parses a tracestate string and returns a Tracestate.
+//
+// Multiple tracestate headers must be combined with a comma. Empty list-members are skipped.
+// An error is returned if a key or value is invalid, a key is duplicated or the tracestate contains more than
+// MaxTracestateMembers list-members. In this case the tracestate must not be propagated.
+func ParseTracestate(tracestate string) (*Tracestate, error) {
+	t := &Tracestate{}
+	seen := map[string]bool{}
+	for _, m := range strings.Split(tracestate, ",") {
+		m = strings.Trim(m, " \t")
+		if m == "" {
+			continue
+		}
+		i := strings.IndexByte(m, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid list-member '%v'", m)
+		}
+		key, value := m[:i], m[i+1:]
+		if err := validateTracestateMember(key, value); err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate key '%v'", key)
+		}
+		seen[key] = true
+		t.members = append(t.members, tracestateMember{key: key, value: value})
+	}
+	if len(t.members) > MaxTracestateMembers {
+		return nil, errors.New("too many list-members")
+	}
+	return t, nil
+}
+
+func validateTracestateMember(key string, value string) error {
+	if !tracestateKey.MatchString(key) {
+		return fmt.Errorf("invalid key '%v'", key)
+	}
+	if !tracestateValue.MatchString(value) {
+		return fmt.Errorf("invalid value '%v' for key '%v'", value, key)
+	}
+	return nil
+}
+
+// Get returns the value of the key.
+func (t *Tracestate) Get(key string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	for _, m := range t.members {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+	return "", false
+}
+
+// Len returns the number of list-members.
+func (t *Tracestate) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.members)
+}
+
+// Set returns a copy of the Tracestate in which the key has the value and is moved to the front,
+// as required for a vendor which modifies its entry. If the Tracestate already contains MaxTracestateMembers
+// list-members the last one is removed.
+//
+// An error is returned if the key or value is invalid.
+func (t *Tracestate) Set(key string, value string) (*Tracestate, error) {
+	if err := validateTracestateMember(key, value); err != nil {
+		return nil, err
+	}
+	members := make([]tracestateMember, 0, t.Len()+1)
+	members = append(members, tracestateMember{key: key, value: value})
+	members = append(members, t.Delete(key).members...)
+	if len(members) > MaxTracestateMembers {
+		members = members[:MaxTracestateMembers]
+	}
+	return &Tracestate{members: members}, nil
+}
+
+// Delete returns a copy of the Tracestate without the key.
+func (t *Tracestate) Delete(key string) *Tracestate {
+	members := make([]tracestateMember, 0, t.Len())
+	if t != nil {
+		for _, m := range t.members {
+			if m.key != key {
+				members = append(members, m)
+			}
+		}
+	}
+	return &Tracestate{members: members}
+}
+
+// String returns the string representation of the tracestate.
+func (t *Tracestate) String() string {
+	if t == nil {
+		return ""
+	}
+	parts := make([]string, len(t.members))
+	for i, m := range t.members {
+		parts[i] = m.key + "=" + m.value
+	}
+	return strings.Join(parts, ",")
+}
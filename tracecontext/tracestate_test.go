@@ -0,0 +1,158 @@
+package tracecontext_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/d-velop/dvelop-sdk-go/tracecontext"
+)
+
+func TestValidTracestate_ParseTracestate_ReturnsTracestate(t *testing.T) {
+	testcases := map[string]string{
+		"congo=t61rcWkgMzE":                           "congo=t61rcWkgMzE",
+		"rojo=00f067aa0ba902b7,congo=t61rcWkgMzE":     "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE",
+		"rojo=00f067aa0ba902b7 ,\t congo=t61rcWkgMzE": "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE",
+		"rojo=1,,congo=2,":                            "rojo=1,congo=2",
+		"fw529a3039@dt=abc,1tenant@vendor=x y":        "fw529a3039@dt=abc,1tenant@vendor=x y",
+		"a*b/c_d-e=!\"#$%&'()*+-./:;<>?@[\\]^_`{|}~":  "a*b/c_d-e=!\"#$%&'()*+-./:;<>?@[\\]^_`{|}~",
+		"": "",
+	}
+
+	for value, want := range testcases {
+		t.Run(value, func(t *testing.T) {
+			ts, err := tracecontext.ParseTracestate(value)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := ts.String(); got != want {
+				t.Errorf("wrong tracestate: got %v want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestInvalidTracestate_ParseTracestate_ReturnsError(t *testing.T) {
+	testcases := map[string]string{
+		"NoEquals":        "congo",
+		"UppercaseKey":    "Congo=1",
+		"KeyStartsDigit":  "1congo=1",
+		"EmptyKey":        "=1",
+		"EmptyValue":      "congo=",
+		"ValueWithComma":  "congo=a\x2cb=c,=",
+		"ValueWithEquals": "congo=a=b",
+		"ValueNonAscii":   "congo=ä",
+		"LongSystemId":    "tenant@abcdefghijklmno=1",
+		"DuplicateKey":    "congo=1,rojo=2,congo=3",
+		"TooManyMembers":  members(33),
+		"KeyTooLong":      "a" + strings.Repeat("b", 256) + "=1",
+		"ValueTooLong":    "congo=" + strings.Repeat("a", 257),
+	}
+
+	for name, value := range testcases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := tracecontext.ParseTracestate(value); err == nil {
+				t.Errorf("expected error for %q", value)
+			}
+		})
+	}
+}
+
+func TestMaxMembers_ParseTracestate_ReturnsTracestate(t *testing.T) {
+	ts, err := tracecontext.ParseTracestate(members(32))
+
+	if err != nil || ts.Len() != 32 {
+		t.Errorf("expected 32 members: got %v, %v", ts.Len(), err)
+	}
+}
+
+func TestTracestate_Get(t *testing.T) {
+	ts, _ := tracecontext.ParseTracestate("rojo=00f067aa0ba902b7,congo=t61rcWkgMzE")
+
+	if v, ok := ts.Get("congo"); !ok || v != "t61rcWkgMzE" {
+		t.Errorf("wrong value: got %v, %v", v, ok)
+	}
+	if _, ok := ts.Get("missing"); ok {
+		t.Error("expected missing key")
+	}
+}
+
+func TestExistingKey_Set_MovesUpdatedMemberToFront(t *testing.T) {
+	ts, _ := tracecontext.ParseTracestate("rojo=00f067aa0ba902b7,congo=t61rcWkgMzE")
+
+	updated, err := ts.Set("congo", "new")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := updated.String(); got != "congo=new,rojo=00f067aa0ba902b7" {
+		t.Errorf("wrong tracestate: got %v", got)
+	}
+	if got := ts.String(); got != "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE" {
+		t.Errorf("original tracestate must not be modified: got %v", got)
+	}
+}
+
+func TestNewKey_Set_AddsMemberToFront(t *testing.T) {
+	ts, _ := tracecontext.ParseTracestate("rojo=00f067aa0ba902b7")
+
+	updated, _ := ts.Set("congo", "t61rcWkgMzE")
+
+	if got := updated.String(); got != "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7" {
+		t.Errorf("wrong tracestate: got %v", got)
+	}
+}
+
+func TestFullTracestate_Set_RemovesLastMember(t *testing.T) {
+	ts, _ := tracecontext.ParseTracestate(members(32))
+
+	updated, _ := ts.Set("congo", "1")
+
+	if updated.Len() != 32 {
+		t.Errorf("wrong number of members: got %v want %v", updated.Len(), 32)
+	}
+	if _, ok := updated.Get("k31"); ok {
+		t.Error("last member should have been removed")
+	}
+	if _, ok := updated.Get("congo"); !ok {
+		t.Error("new member should have been added")
+	}
+}
+
+func TestInvalidMember_Set_ReturnsError(t *testing.T) {
+	ts, _ := tracecontext.ParseTracestate("rojo=00f067aa0ba902b7")
+
+	if _, err := ts.Set("Congo", "1"); err == nil {
+		t.Error("expected error for invalid key")
+	}
+	if _, err := ts.Set("congo", "a,b"); err == nil {
+		t.Error("expected error for invalid value")
+	}
+}
+
+func TestTracestate_Delete(t *testing.T) {
+	ts, _ := tracecontext.ParseTracestate("rojo=00f067aa0ba902b7,congo=t61rcWkgMzE")
+
+	if got := ts.Delete("rojo").String(); got != "congo=t61rcWkgMzE" {
+		t.Errorf("wrong tracestate: got %v", got)
+	}
+}
+
+func TestNilTracestate_IsEmpty(t *testing.T) {
+	var ts *tracecontext.Tracestate
+
+	updated, err := ts.Set("congo", "1")
+
+	if ts.Len() != 0 || ts.String() != "" || err != nil || updated.String() != "congo=1" {
+		t.Errorf("nil tracestate should behave like an empty one: got %v, %v", updated, err)
+	}
+}
+
+func members(n int) string {
+	m := make([]string, n)
+	for i := range m {
+		m[i] = fmt.Sprintf("k%v=v%v", i, i)
+	}
+	return strings.Join(m, ",")
+}
@@ -0,0 +1,122 @@
+package tracecontext
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/d-velop/dvelop-sdk-go/otellog"
+)
+
+// A TransportOption configures the behaviour of Transport.
+type TransportOption func(*transport)
+
+// TransportLogging logs an otellog event for each outbound request of a sampled trace, which contains the method,
+// url, status code and duration of the request in the attribute Http.Client. By default no events are logged.
+func TransportLogging() TransportOption {
+	return func(t *transport) {
+		t.logging = true
+	}
+}
+
+type transport struct {
+	next    http.RoundTripper
+	logging bool
+}
+
+// Transport returns a http.RoundTripper which passes the trace context of the request context on to downstream services.
+//
+// Each outbound request is a child span of the current span, so a new span-id is generated and sent together with
+// the trace-id and trace-flags of the context in the http header traceparent. The tracestate from the context is
+// sent in the http header tracestate. Requests which already contain a traceparent or whose context doesn't contain
+// a trace-id and span-id are passed on unchanged. If next is nil http.DefaultTransport is used.
+//
+// Example:
+//	client := &http.Client{
+//		Transport: tracecontext.Transport(nil, tracecontext.TransportLogging()),
+//	}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://otherservice.example.com/resource", nil)
+//	resp, err := client.Do(req)
+func Transport(next http.RoundTripper, options ...TransportOption) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &transport{next: next}
+	for _, o := range options {
+		o(t)
+	}
+	return t
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	outReq := req
+	if req.Header.Get(traceparentHeader) == "" {
+		if childCtx, traceparent, err := childSpan(ctx); err == nil {
+			ctx = childCtx
+			// a RoundTripper must not modify the original request cf. https://golang.org/pkg/net/http/#RoundTripper
+			outReq = req.Clone(req.Context())
+			outReq.Header.Set(traceparentHeader, traceparent)
+			if tracestate, err := TracestateFromCtx(ctx); err == nil && tracestate.Len() > 0 {
+				outReq.Header.Set(tracestateHeader, tracestate.String())
+			}
+		}
+	}
+
+	if !t.logging || !IsSampled(ctx) {
+		return t.next.RoundTrip(outReq)
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(outReq)
+	logRoundTrip(ctx, outReq, resp, err, time.Since(start))
+	return resp, err
+}
+
+// childSpan returns a new context for a child span of the current span and the traceparent of the child span.
+func childSpan(ctx context.Context) (context.Context, string, error) {
+	if _, err := TraceIdFromCtx(ctx); err != nil {
+		return nil, "", err
+	}
+	spanId, err := SpanIdFromCtx(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	childSpanId, err := NewSpanId()
+	if err != nil {
+		return nil, "", err
+	}
+	childCtx := WithParentSpanIdCtx(WithSpanIdCtx(ctx, childSpanId), spanId)
+	traceparent, err := TraceparentFromCtx(childCtx)
+	if err != nil {
+		return nil, "", err
+	}
+	return childCtx, traceparent, nil
+}
+
+// logRoundTrip logs the outbound request with severity info even if it failed, because the caller might compensate the error.
+func logRoundTrip(ctx context.Context, req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	req = withoutQuery(req)
+	logger := otellog.WithName("HttpClientRequest").
+		With(func(e *otellog.Event) { LogHook(ctx, e) }).
+		WithHttpRequest(req).
+		With(func(e *otellog.Event) { e.Attributes.Http.Client = &otellog.Client{Duration: duration} })
+	if err != nil {
+		logger.Infof(ctx, "Outbound request %v %v failed: %v", req.Method, req.URL, err)
+		return
+	}
+	logger.WithHttpStatusCode(resp.StatusCode).Infof(ctx, "Outbound request %v %v with status code '%v'", req.Method, req.URL, resp.StatusCode)
+}
+
+// withoutQuery returns a shallow copy of the request whose url contains neither userinfo nor query and fragment,
+// because they might contain credentials like access tokens which must not be logged.
+func withoutQuery(req *http.Request) *http.Request {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	r := req.WithContext(req.Context())
+	r.URL = &u
+	return r
+}